@@ -0,0 +1,160 @@
+// Package chunking splits text into chunks suitable for embedding.
+package chunking
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrInvalidSize    = errors.New("chunk size must be positive")
+	ErrInvalidOverlap = errors.New("overlap must be non-negative and less than chunk size")
+)
+
+func validate(size, overlap int) error {
+	if size <= 0 {
+		return ErrInvalidSize
+	}
+	if overlap < 0 || overlap >= size {
+		return fmt.Errorf("%w: size %d, overlap %d", ErrInvalidOverlap, size, overlap)
+	}
+	return nil
+}
+
+// window splits n items into [start, end) ranges of size with overlap.
+func window(n, size, overlap int) [][2]int {
+	var res [][2]int
+	step := size - overlap
+	for start := 0; start < n; start += step {
+		end := min(start+size, n)
+		res = append(res, [2]int{start, end})
+		if end == n {
+			break
+		}
+	}
+	return res
+}
+
+// Fixed splits text into chunks of size runes, each chunk repeating
+// the last overlap runes of the previous one.
+func Fixed(text string, size, overlap int) ([]string, error) {
+	if err := validate(size, overlap); err != nil {
+		return nil, err
+	}
+	runes := []rune(text)
+	var chunks []string
+	for _, w := range window(len(runes), size, overlap) {
+		chunks = append(chunks, string(runes[w[0]:w[1]]))
+	}
+	return chunks, nil
+}
+
+// Tokens splits text into chunks of size whitespace separated tokens,
+// each chunk repeating the last overlap tokens of the previous one.
+// Tokens in a chunk are joined with a single space.
+func Tokens(text string, size, overlap int) ([]string, error) {
+	if err := validate(size, overlap); err != nil {
+		return nil, err
+	}
+	tokens := strings.Fields(text)
+	var chunks []string
+	for _, w := range window(len(tokens), size, overlap) {
+		chunks = append(chunks, strings.Join(tokens[w[0]:w[1]], " "))
+	}
+	return chunks, nil
+}
+
+// Sentences splits text into sentences and groups consecutive sentences
+// into chunks not longer than maxChars runes. A single sentence longer
+// than maxChars becomes its own chunk.
+func Sentences(text string, maxChars int) ([]string, error) {
+	if maxChars <= 0 {
+		return nil, ErrInvalidSize
+	}
+	var chunks []string
+	var cur strings.Builder
+	curLen := 0
+	for _, s := range splitSentences(text) {
+		sLen := len([]rune(s))
+		if curLen > 0 && curLen+1+sLen > maxChars {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+			curLen = 0
+		}
+		if curLen > 0 {
+			cur.WriteByte(' ')
+			curLen++
+		}
+		cur.WriteString(s)
+		curLen += sLen
+	}
+	if curLen > 0 {
+		chunks = append(chunks, cur.String())
+	}
+	return chunks, nil
+}
+
+// splitSentences splits text after '.', '!' or '?' followed by whitespace.
+func splitSentences(text string) []string {
+	var res []string
+	runes := []rune(text)
+	start := 0
+	for i, r := range runes {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+		if s := strings.TrimSpace(string(runes[start : i+1])); s != "" {
+			res = append(res, s)
+		}
+		start = i + 1
+	}
+	if s := strings.TrimSpace(string(runes[start:])); s != "" {
+		res = append(res, s)
+	}
+	return res
+}
+
+// MarkdownHeaders splits markdown text into sections starting at ATX header lines.
+// Each chunk includes its header line. Text before the first header is its own chunk.
+// Headers inside fenced code blocks are ignored.
+func MarkdownHeaders(text string) []string {
+	var chunks []string
+	var cur []string
+	inFence := false
+	flush := func() {
+		if s := strings.TrimSpace(strings.Join(cur, "\n")); s != "" {
+			chunks = append(chunks, s)
+		}
+		cur = cur[:0]
+	}
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		if !inFence && isATXHeader(line) {
+			flush()
+		}
+		cur = append(cur, line)
+	}
+	flush()
+	return chunks
+}
+
+// isATXHeader reports whether line starts with 1 to 6 '#' characters
+// followed by a space, a tab or the end of line
+func isATXHeader(line string) bool {
+	n := 0
+	for n < len(line) && line[n] == '#' {
+		n++
+	}
+	if n == 0 || n > 6 {
+		return false
+	}
+	return n == len(line) || line[n] == ' ' || line[n] == '\t' || line[n] == '\r'
+}
@@ -0,0 +1,61 @@
+package chunking
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFixed(t *testing.T) {
+	chunks, err := Fixed("abcdefghij", 4, 1)
+	if err != nil {
+		t.Fatalf("Fixed returned error: %v", err)
+	}
+	expected := []string{"abcd", "defg", "ghij"}
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected %v, got %v", expected, chunks)
+	}
+
+	if _, err := Fixed("abc", 2, 2); !errors.Is(err, ErrInvalidOverlap) {
+		t.Errorf("expected ErrInvalidOverlap, got %v", err)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	chunks, err := Tokens("one two  three\nfour five", 2, 0)
+	if err != nil {
+		t.Fatalf("Tokens returned error: %v", err)
+	}
+	expected := []string{"one two", "three four", "five"}
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected %v, got %v", expected, chunks)
+	}
+}
+
+func TestSentences(t *testing.T) {
+	chunks, err := Sentences("First one. Second one! Third? Version 1.2 is out.", 26)
+	if err != nil {
+		t.Fatalf("Sentences returned error: %v", err)
+	}
+	expected := []string{"First one. Second one!", "Third? Version 1.2 is out."}
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected %v, got %v", expected, chunks)
+	}
+}
+
+func TestMarkdownHeaders(t *testing.T) {
+	text := "intro\n# A\ntext a\n```\n# not a header\n```\n## B\ntext b\n"
+	chunks := MarkdownHeaders(text)
+	expected := []string{"intro", "# A\ntext a\n```\n# not a header\n```", "## B\ntext b"}
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected %q, got %q", expected, chunks)
+	}
+
+	// hashtags, shebangs and over-deep headers are not headers
+	text = "#!/bin/sh\n#hashtag\n####### seven\n#\n###\tC\ntext c"
+	chunks = MarkdownHeaders(text)
+	expected = []string{"#!/bin/sh\n#hashtag\n####### seven", "#", "###\tC\ntext c"}
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected %q, got %q", expected, chunks)
+	}
+}
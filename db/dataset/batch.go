@@ -0,0 +1,180 @@
+package dataset
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+)
+
+// ReadMany retrieves chunks by IDs, returned in the order of ids.
+// Chunks are read in file order and physically adjacent chunks are read
+// with a single read call. Fields select chunk fields as in Read.
+// Returned chunks never share blobs, also when ids repeat.
+func (d *Dataset) ReadMany(ids []uint32, fields ...Field) ([]*Chunk, error) {
+	hooks := d.hookList()
+	for _, id := range ids {
+		for _, h := range hooks {
+			if err := h.BeforeRead(id); err != nil {
+				return nil, fmt.Errorf("read rejected by hook: %w", err)
+			}
+		}
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	recs := make([]index, len(ids))
+	for i, id := range ids {
+		idx, ok := d.index[id]
+		if !ok {
+			return nil, fmt.Errorf("chunk with id %d not found", id)
+		}
+		recs[i] = idx
+	}
+	return d.readMany(recs, newFieldSet(fields))
+}
+
+// readMany reads chunks of index records, returned in the order of recs.
+// Caller must hold the lock.
+func (d *Dataset) readMany(recs []index, fs fieldSet) ([]*Chunk, error) {
+	// Sort positions by file offset
+	order := make([]int, len(recs))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return cmp.Compare(recs[a].Position, recs[b].Position)
+	})
+
+	res := make([]*Chunk, len(recs))
+	dataSpace := d.header.dataSpacePos()
+
+	for start := 0; start < len(order); {
+		// Extend run while next chunk starts where previous ends (or repeats a chunk in the run)
+		first := recs[order[start]]
+		end := start + 1
+		runEnd := first.Position + first.Size
+		for end < len(order) {
+			next := recs[order[end]]
+			if next.Position > runEnd {
+				break
+			}
+			runEnd = max(runEnd, next.Position+next.Size)
+			end++
+		}
+
+		buf := make([]byte, runEnd-first.Position)
+		if _, err := d.f.ReadAt(buf, dataSpace+int64(first.Position)); err != nil {
+			return nil, fmt.Errorf("failed to read chunks: %w", err)
+		}
+
+		for _, i := range order[start:end] {
+			idx := recs[i]
+			// Every chunk gets its own copy, so chunks returned for repeated ids
+			// do not share blobs and do not keep the whole run buffer alive
+			off := idx.Position - first.Position
+			cr, err := parseChunk(bytes.Clone(buf[off : off+idx.Size]))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse chunk %d: %w", idx.ID, err)
+			}
+			res[i] = idx.chunk(cr, fs)
+		}
+		start = end
+	}
+
+	return res, nil
+}
+
+// defaultVectorBatch is the amount of chunks read under one lock hold by Vectors
+const defaultVectorBatch = 256
+
+// Vectors returns an iterator over chunks with a non-empty vector in ascending ID order.
+// Only the Vector field is loaded: for every chunk the sizes header and the
+// vector section are read, data and meta are skipped. The lock is taken once
+// per batchSize chunks (zero means default). Unlike List, the dataset lock is not
+// held while yielding, so the loop body may call dataset methods.
+// Chunks deleted during iteration are skipped, chunks added after the
+// iteration started are not yielded.
+func (d *Dataset) Vectors(batchSize int) iter.Seq2[*Chunk, error] {
+	if batchSize <= 0 {
+		batchSize = defaultVectorBatch
+	}
+	return func(yield func(*Chunk, error) bool) {
+		d.Lock()
+		ids := slices.Sorted(maps.Keys(d.index))
+		d.Unlock()
+
+		hooks := d.hookList()
+		for batch := range slices.Chunk(ids, batchSize) {
+			for _, id := range batch {
+				for _, h := range hooks {
+					if err := h.BeforeRead(id); err != nil {
+						yield(nil, fmt.Errorf("read rejected by hook: %w", err))
+						return
+					}
+				}
+			}
+
+			d.Lock()
+			chunks := make([]*Chunk, 0, len(batch))
+			var err error
+			for _, id := range batch {
+				idx, ok := d.index[id]
+				if !ok {
+					continue
+				}
+				var c *Chunk
+				if c, err = d.readVector(&idx); err != nil {
+					break
+				}
+				chunks = append(chunks, c)
+			}
+			d.Unlock()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, c := range chunks {
+				if len(c.Vector.Blob()) == 0 {
+					continue
+				}
+				if !yield(c, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// readVector reads the vector of chunk of idx. Data and meta are skipped
+// by offset as in readChunkFields. Caller must hold the lock.
+func (d *Dataset) readVector(idx *index) (*Chunk, error) {
+	pos := d.header.dataSpacePos() + int64(idx.Position)
+	var sizeBuf [sizeChunkHeader]byte
+	if _, err := d.f.ReadAt(sizeBuf[:], pos); err != nil {
+		return nil, fmt.Errorf("failed to read chunk sizes: %w", err)
+	}
+	dataSize := binary.LittleEndian.Uint64(sizeBuf[0:])
+	metaSize := binary.LittleEndian.Uint32(sizeBuf[8:])
+	vectorSize := binary.LittleEndian.Uint32(sizeBuf[12:])
+	if err := checkChunkSizes(idx.Size, dataSize, metaSize, vectorSize); err != nil {
+		return nil, err
+	}
+
+	c := &Chunk{ID: idx.ID, Date: idx.Date, Flags: idx.Flags}
+	var vectorBlob []byte
+	if vectorSize > 0 {
+		vectorBlob = make([]byte, vectorSize)
+		off := pos + sizeChunkHeader + int64(dataSize) + int64(metaSize)
+		if _, err := d.f.ReadAt(vectorBlob, off); err != nil {
+			return nil, fmt.Errorf("failed to read vector blob: %w", err)
+		}
+	}
+	c.Vector = NewByteUnit(vectorBlob, idx.VectorDesc)
+	return c, nil
+}
@@ -0,0 +1,88 @@
+package dataset
+
+import "container/list"
+
+// chunkCache is a size-bounded LRU cache of chunk records.
+// It is not safe for concurrent use, the dataset lock protects it.
+type chunkCache struct {
+	maxBytes int64
+	bytes    int64
+	ll       *list.List
+	items    map[uint32]*list.Element
+}
+
+type cacheEntry struct {
+	id  uint32
+	buf []byte // raw chunk record
+}
+
+func newChunkCache(maxBytes int64) *chunkCache {
+	return &chunkCache{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[uint32]*list.Element),
+	}
+}
+
+// get returns a copy of the cached chunk record for id.
+func (c *chunkCache) get(id uint32) (*chunkRecord, bool) {
+	el, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	buf := el.Value.(*cacheEntry).buf
+	cp := make([]byte, len(buf))
+	copy(cp, buf)
+	cr, err := parseChunk(cp)
+	return cr, err == nil
+}
+
+// peek returns cached raw chunk record for id without copying.
+func (c *chunkCache) peek(id uint32) ([]byte, bool) {
+	el, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).buf, true
+}
+
+// put stores raw chunk record for id, evicting least recently used records.
+// Records larger than the cache are not stored. buf must not be modified later.
+func (c *chunkCache) put(id uint32, buf []byte) {
+	size := int64(len(buf))
+	if size > c.maxBytes {
+		return
+	}
+	c.remove(id)
+	c.items[id] = c.ll.PushFront(&cacheEntry{id: id, buf: buf})
+	c.bytes += size
+	for c.bytes > c.maxBytes {
+		c.remove(c.ll.Back().Value.(*cacheEntry).id)
+	}
+}
+
+// remove drops id from the cache.
+func (c *chunkCache) remove(id uint32) {
+	el, ok := c.items[id]
+	if !ok {
+		return
+	}
+	c.ll.Remove(el)
+	delete(c.items, id)
+	c.bytes -= int64(len(el.Value.(*cacheEntry).buf))
+}
+
+// SetCache enables a cache of recently read chunks bounded by maxBytes.
+// Chunks are cached on full reads and invalidated on Update and Delete.
+// Zero or negative maxBytes disables the cache.
+func (d *Dataset) SetCache(maxBytes int64) {
+	d.Lock()
+	defer d.Unlock()
+	if maxBytes <= 0 {
+		d.cache = nil
+		return
+	}
+	d.cache = newChunkCache(maxBytes)
+}
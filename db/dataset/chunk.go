@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 )
 
 // Field specifies which chunk fields to read
@@ -46,6 +47,49 @@ type Chunk struct {
 	Vector Unit
 }
 
+// fieldSet tells which chunk fields are selected
+type fieldSet struct {
+	data, meta, vector bool
+}
+
+// newFieldSet creates a fieldSet from fields, empty fields select all.
+func newFieldSet(fields []Field) fieldSet {
+	if len(fields) == 0 {
+		return fieldSet{data: true, meta: true, vector: true}
+	}
+	var fs fieldSet
+	for _, f := range fields {
+		switch f {
+		case FieldData:
+			fs.data = true
+		case FieldMeta:
+			fs.meta = true
+		case FieldVector:
+			fs.vector = true
+		}
+	}
+	return fs
+}
+
+// chunk builds Chunk with selected fields from index metadata and chunk record.
+func (i *index) chunk(cr *chunkRecord, fs fieldSet) *Chunk {
+	c := &Chunk{
+		ID:    i.ID,
+		Date:  i.Date,
+		Flags: i.Flags,
+	}
+	if fs.data {
+		c.Data = NewByteUnit(cr.Data, i.DataDesc)
+	}
+	if fs.meta {
+		c.Meta = NewByteUnit(cr.Meta, i.MetaDesc)
+	}
+	if fs.vector {
+		c.Vector = NewByteUnit(cr.Vector, i.VectorDesc)
+	}
+	return c
+}
+
 // chunkRecord represents how chunk data is saved to the file
 type chunkRecord struct {
 	dataSize   uint64
@@ -60,9 +104,41 @@ func (cr *chunkRecord) size() int64 {
 	return int64(8 + 4 + 4 + cr.dataSize + uint64(cr.metaSize) + uint64(cr.vectorSize))
 }
 
+// maxPooledBuf limits size of buffers returned to writeBufPool
+const maxPooledBuf = 1 << 20
+
+// writeBufPool holds buffers used to serialize chunk records for writing
+var writeBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 4096)
+		return &b
+	},
+}
+
 // write writes chunk to file at current position (must be at end of file)
 func (cr *chunkRecord) write(f *os.File) error {
-	buf := make([]byte, cr.size())
+	bp := writeBufPool.Get().(*[]byte)
+	buf := cr.encode(*bp)
+	_, err := f.Write(buf)
+	if cap(buf) <= maxPooledBuf {
+		*bp = buf[:0]
+		writeBufPool.Put(bp)
+	}
+	return err
+}
+
+// blob serializes chunk record into a new buffer
+func (cr *chunkRecord) blob() []byte {
+	return cr.encode(nil)
+}
+
+// encode serializes chunk record reusing buf capacity when possible
+func (cr *chunkRecord) encode(buf []byte) []byte {
+	size := int(cr.size())
+	if cap(buf) < size {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
 	offset := 0
 	binary.LittleEndian.PutUint64(buf[offset:], cr.dataSize)
 	offset += 8
@@ -75,22 +151,37 @@ func (cr *chunkRecord) write(f *os.File) error {
 	copy(buf[offset:], cr.Meta)
 	offset += int(cr.metaSize)
 	copy(buf[offset:], cr.Vector)
-
-	_, err := f.Write(buf)
-	return err
+	return buf
 }
 
+// sizeChunkHeader is the size of chunk blob sizes preceding the blobs
+const sizeChunkHeader = 16
+
 func readChunk(f *os.File, size uint64) (*chunkRecord, error) {
+	if size < sizeChunkHeader {
+		return nil, fmt.Errorf("%w: chunk size %d is too small", ErrCorrupted, size)
+	}
 	buf := make([]byte, size)
 	if _, err := io.ReadFull(f, buf); err != nil {
 		return nil, fmt.Errorf("failed to read chunk: %w", err)
 	}
+	return parseChunk(buf)
+}
 
+// parseChunk parses chunk record from buf, blobs reference buf.
+// Blob sizes must add up to the buffer size.
+func parseChunk(buf []byte) (*chunkRecord, error) {
+	if len(buf) < sizeChunkHeader {
+		return nil, fmt.Errorf("%w: chunk size %d is too small", ErrCorrupted, len(buf))
+	}
 	dataSize := binary.LittleEndian.Uint64(buf[0:])
 	metaSize := binary.LittleEndian.Uint32(buf[8:])
 	vectorSize := binary.LittleEndian.Uint32(buf[12:])
+	if err := checkChunkSizes(uint64(len(buf)), dataSize, metaSize, vectorSize); err != nil {
+		return nil, err
+	}
 
-	offset := uint64(16)
+	offset := uint64(sizeChunkHeader)
 	cr := &chunkRecord{
 		dataSize:   dataSize,
 		metaSize:   metaSize,
@@ -102,6 +193,14 @@ func readChunk(f *os.File, size uint64) (*chunkRecord, error) {
 	return cr, nil
 }
 
+// checkChunkSizes verifies that blob sizes add up to the chunk size without overflow
+func checkChunkSizes(size, dataSize uint64, metaSize, vectorSize uint32) error {
+	if dataSize > size || sizeChunkHeader+dataSize+uint64(metaSize)+uint64(vectorSize) != size {
+		return fmt.Errorf("%w: chunk blob sizes %d, %d, %d do not match chunk size %d", ErrCorrupted, dataSize, metaSize, vectorSize, size)
+	}
+	return nil
+}
+
 // readChunkFields loads specified fields into an existing Chunk.
 // The chunk must have index metadata already populated.
 // Only fields in the slice are loaded; others remain nil.
@@ -111,18 +210,7 @@ func (d *Dataset) readChunkFields(c *Chunk, idx *index, fields []Field) error {
 		return nil
 	}
 
-	// Determine which fields to read
-	readData, readMeta, readVector := false, false, false
-	for _, f := range fields {
-		switch f {
-		case FieldData:
-			readData = true
-		case FieldMeta:
-			readMeta = true
-		case FieldVector:
-			readVector = true
-		}
-	}
+	fs := newFieldSet(fields)
 
 	// Seek to chunk position
 	pos := d.header.dataSpacePos() + int64(idx.Position)
@@ -130,8 +218,8 @@ func (d *Dataset) readChunkFields(c *Chunk, idx *index, fields []Field) error {
 		return fmt.Errorf("failed to seek to chunk: %w", err)
 	}
 
-	// Read sizes header (16 bytes)
-	var sizeBuf [16]byte
+	// Read sizes header
+	var sizeBuf [sizeChunkHeader]byte
 	if _, err := io.ReadFull(d.f, sizeBuf[:]); err != nil {
 		return fmt.Errorf("failed to read chunk sizes: %w", err)
 	}
@@ -139,9 +227,12 @@ func (d *Dataset) readChunkFields(c *Chunk, idx *index, fields []Field) error {
 	dataSize := binary.LittleEndian.Uint64(sizeBuf[0:])
 	metaSize := binary.LittleEndian.Uint32(sizeBuf[8:])
 	vectorSize := binary.LittleEndian.Uint32(sizeBuf[12:])
+	if err := checkChunkSizes(idx.Size, dataSize, metaSize, vectorSize); err != nil {
+		return err
+	}
 
 	// Read data blob
-	if readData && dataSize > 0 {
+	if fs.data && dataSize > 0 {
 		dataBlob := make([]byte, dataSize)
 		if _, err := io.ReadFull(d.f, dataBlob); err != nil {
 			return fmt.Errorf("failed to read data blob: %w", err)
@@ -155,7 +246,7 @@ func (d *Dataset) readChunkFields(c *Chunk, idx *index, fields []Field) error {
 	}
 
 	// Read meta blob
-	if readMeta && metaSize > 0 {
+	if fs.meta && metaSize > 0 {
 		metaBlob := make([]byte, metaSize)
 		if _, err := io.ReadFull(d.f, metaBlob); err != nil {
 			return fmt.Errorf("failed to read meta blob: %w", err)
@@ -169,7 +260,7 @@ func (d *Dataset) readChunkFields(c *Chunk, idx *index, fields []Field) error {
 	}
 
 	// Read vector blob
-	if readVector && vectorSize > 0 {
+	if fs.vector && vectorSize > 0 {
 		vectorBlob := make([]byte, vectorSize)
 		if _, err := io.ReadFull(d.f, vectorBlob); err != nil {
 			return fmt.Errorf("failed to read vector blob: %w", err)
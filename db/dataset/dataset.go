@@ -1,8 +1,11 @@
 package dataset
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math"
 	"os"
 	"sync"
 	"time"
@@ -11,17 +14,30 @@ import (
 const (
 	sizeMagic    = 4       // magic bytes
 	size32       = 4       // 32 bit word size
+	size64       = 8       // 64 bit word size
 	sizeIndexRec = 32      // index record size
 	maxIndexCap  = 1 << 24 // ~16 million records, ~512MB index space
 )
 
+// ErrLocked is returned when the dataset file is open by another Dataset
+var ErrLocked = errors.New("dataset file is locked")
+
 type Dataset struct {
 	sync.Mutex
-	f      *os.File
+	f *os.File
+	// lock is the locked <path>.lock file held while the dataset is open
+	lock   *os.File
 	path   string
 	header *header
 	index  map[uint32]index
 	lastID uint32
+	logger *slog.Logger
+	hooks  []Hook
+	// hashes maps content hash to ids of live chunks with that content,
+	// nil until first AppendUnique
+	hashes   map[contentHash][]uint32
+	hashByID map[uint32]contentHash
+	cache    *chunkCache
 }
 
 // Info contains dataset header information for inspection without keeping file open.
@@ -41,9 +57,20 @@ func NewDataset(path string, signature uint32, config []byte, indexCap int) (*Da
 		return nil, fmt.Errorf("indexCap must be between 1 and %d", maxIndexCap)
 	}
 
-	// Create the file
+	// Take the lock before an existing file is truncated
+	lock, err := acquireLock(path)
+	if err != nil {
+		return nil, err
+	}
 	f, err := os.Create(path)
 	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	// Hashes of a previous file at path must not be picked up
+	if err := removeHashFile(path); err != nil {
+		f.Close()
+		lock.Close()
 		return nil, err
 	}
 
@@ -60,6 +87,7 @@ func NewDataset(path string, signature uint32, config []byte, indexCap int) (*Da
 	// Write header blob in single operation
 	if _, err := f.Write(h.blob()); err != nil {
 		f.Close()
+		lock.Close()
 		return nil, fmt.Errorf("failed to write header: %w", err)
 	}
 
@@ -67,26 +95,51 @@ func NewDataset(path string, signature uint32, config []byte, indexCap int) (*Da
 	totalSize := int64(len(h.blob())) + int64(indexCap*sizeIndexRec)
 	if err := f.Truncate(totalSize); err != nil {
 		f.Close()
+		lock.Close()
 		return nil, fmt.Errorf("failed to allocate index space: %w", err)
 	}
 
 	// Initialize and return Dataset
 	return &Dataset{
 		f:      f,
+		lock:   lock,
 		path:   path,
 		header: h,
 		index:  make(map[uint32]index, indexCap),
 		lastID: 0,
+		logger: discardLogger,
 	}, nil
 }
 
-// Close closes the dataset and releases resources.
+// discardLogger is used until a logger is set with SetLogger.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// SetLogger sets the logger used for debug output. Nil disables logging.
+func (d *Dataset) SetLogger(l *slog.Logger) {
+	d.Lock()
+	defer d.Unlock()
+	if l == nil {
+		l = discardLogger
+	}
+	d.logger = l
+}
+
+// Close closes the dataset and releases resources including the file lock.
+// Content hashes built by AppendUnique are saved to the <path>.hashes file.
 func (d *Dataset) Close() error {
 	d.Lock()
-	f := d.f
-	d.f = nil
+	var hashErr error
+	if d.f != nil && d.hashes != nil {
+		hashErr = d.saveHashFile()
+	}
+	f, lock := d.f, d.lock
+	d.f, d.lock = nil, nil
 	d.Unlock()
-	return f.Close()
+	err := f.Close()
+	if lock != nil {
+		lock.Close()
+	}
+	return errors.Join(err, hashErr)
 }
 
 func (d *Dataset) ChangeIndexCap(newCap int, useLock bool) error {
@@ -123,6 +176,8 @@ func (d *Dataset) ChangeIndexCap(newCap int, useLock bool) error {
 // copyIndexCount specifies how many index records to copy from original.
 // Caller must hold the lock.
 func (d *Dataset) rewriteFile(newHeader *header, newIndexCap uint32, copyIndexCount uint32) error {
+	start := time.Now()
+
 	// Create temporary file
 	tmpPath := d.path + ".tmp"
 	tmpFile, err := os.Create(tmpPath)
@@ -182,26 +237,62 @@ func (d *Dataset) rewriteFile(newHeader *header, newIndexCap uint32, copyIndexCo
 	if err := tmpFile.Close(); err != nil {
 		return fmt.Errorf("failed to close temp file: %w", err)
 	}
+	newFile, err := d.replaceFile(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	// Update Dataset state
+	d.f = newFile
+	d.header = newHeader
+	success = true
+	d.logger.Debug("dataset file rewritten", "path", d.path, "indexCap", newIndexCap, "duration", time.Since(start))
+	return nil
+}
+
+// replaceFile closes the dataset file, replaces it with tmpPath and returns the reopened file.
+// The original file must be closed before rename as Windows does not allow
+// replacing a file with open handles. The lock file stays locked meanwhile,
+// so no other Dataset can open the file in between. If rename fails tmpPath
+// is removed and the original file is reopened so that the dataset stays
+// usable. If the file cannot be reopened d.f is left nil and further
+// operations fail. Caller must hold the lock.
+func (d *Dataset) replaceFile(tmpPath string) (*os.File, error) {
 	if err := d.f.Close(); err != nil {
-		return fmt.Errorf("failed to close original file: %w", err)
+		return nil, fmt.Errorf("failed to close original file: %w", err)
 	}
+	d.f = nil
 
 	// Atomically replace original with temp
 	if err := os.Rename(tmpPath, d.path); err != nil {
-		return fmt.Errorf("failed to replace file: %w", err)
+		os.Remove(tmpPath)
+		if f, rerr := os.OpenFile(d.path, os.O_RDWR, 0644); rerr == nil {
+			d.f = f
+		}
+		return nil, fmt.Errorf("failed to replace file: %w", err)
 	}
 
 	// Reopen the file
-	newFile, err := os.OpenFile(d.path, os.O_RDWR, 0644)
+	f, err := os.OpenFile(d.path, os.O_RDWR, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to reopen file: %w", err)
+		return nil, fmt.Errorf("failed to reopen file: %w", err)
 	}
+	return f, nil
+}
 
-	// Update Dataset state
-	d.f = newFile
-	d.header = newHeader
-	success = true
-	return nil
+// acquireLock creates and locks the lock file of dataset at path.
+// The lock file is kept after the lock is released: removing it would let
+// a waiting opener lock an unlinked file.
+func acquireLock(path string) (*os.File, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	return lock, nil
 }
 
 // UpdateConfig updates the dataset configuration.
@@ -244,9 +335,11 @@ func (d *Dataset) Signature() uint32 {
 	return d.header.signature
 }
 
-// OpenDataset function opens existing dataset file
+// OpenDataset function opens existing dataset file.
+// Records torn by an interrupted write at the end of data space are repaired
+// before loading the index, Repair checks the whole file.
 func OpenDataset(path string) (*Dataset, error) {
-	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+	f, lock, err := openLocked(path)
 	if err != nil {
 		return nil, err
 	}
@@ -254,24 +347,49 @@ func OpenDataset(path string) (*Dataset, error) {
 	h, err := readHeader(f)
 	if err != nil {
 		f.Close()
+		lock.Close()
+		return nil, err
+	}
+
+	if _, err := repair(f, h, false); err != nil {
+		f.Close()
+		lock.Close()
 		return nil, err
 	}
 
 	index, lastID, err := readIndex(f, h)
 	if err != nil {
 		f.Close()
+		lock.Close()
 		return nil, err
 	}
 
 	return &Dataset{
 		f:      f,
+		lock:   lock,
 		path:   path,
 		header: h,
 		index:  index,
 		lastID: lastID,
+		logger: discardLogger,
 	}, nil
 }
 
+// openLocked opens existing dataset file at path for writing and takes its lock.
+// The file is opened first so that no lock file is created for a missing dataset.
+func openLocked(path string) (f, lock *os.File, err error) {
+	f, err = os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	lock, err = acquireLock(path)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, lock, nil
+}
+
 // ReadInfo reads dataset header information without keeping file open.
 func ReadInfo(path string) (*Info, error) {
 	f, err := os.Open(path)
@@ -293,13 +411,82 @@ func ReadInfo(path string) (*Info, error) {
 	}, nil
 }
 
-// Append adds chunk data to file and returns id of added chunk
+// Append adds chunk data to file and returns id of added chunk.
+// Registered hooks are called before and after the chunk is written.
 func (d *Dataset) Append(data, meta, vector Unit) (uint32, error) {
+	return d.appendWithHooks(0, data, meta, vector)
+}
+
+// AppendAt adds chunk data to file with explicit id, which must not belong to a live chunk.
+// IDs between the last allocated ID and id are left as holes and are never
+// assigned automatically. Registered hooks are called as for Append.
+func (d *Dataset) AppendAt(id uint32, data, meta, vector Unit) error {
+	if id == 0 {
+		return fmt.Errorf("id cannot be zero")
+	}
+	_, err := d.appendWithHooks(id, data, meta, vector)
+	return err
+}
+
+// ReserveIDs allocates n sequential IDs without writing chunks and returns the first one.
+// Reserved IDs are never assigned by Append and can be filled with AppendAt.
+// Reservation is kept in memory only: after reopen the next automatic ID
+// follows the highest ID present in the index.
+func (d *Dataset) ReserveIDs(n int) (uint32, error) {
 	d.Lock()
 	defer d.Unlock()
 
+	if n <= 0 {
+		return 0, fmt.Errorf("amount of IDs must be positive")
+	}
+	if uint64(d.lastID)+uint64(n) > math.MaxUint32 {
+		return 0, fmt.Errorf("ID space exhausted")
+	}
+	first := d.lastID + 1
+	d.lastID += uint32(n)
+	return first, nil
+}
+
+// appendWithHooks runs append hooks around append, zero id means next automatic ID.
+func (d *Dataset) appendWithHooks(id uint32, data, meta, vector Unit) (uint32, error) {
+	hooks := d.hookList()
+	c := &Chunk{Data: data, Meta: meta, Vector: vector}
+	for _, h := range hooks {
+		if err := h.BeforeAppend(c); err != nil {
+			return 0, fmt.Errorf("append rejected by hook: %w", err)
+		}
+	}
+
+	d.Lock()
+	id, err := d.append(id, c.Data, c.Meta, c.Vector)
+	d.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	c.ID = id
+	for _, h := range hooks {
+		h.AfterAppend(c)
+	}
+	return id, nil
+}
+
+// append writes chunk data to file and returns id of added chunk.
+// Zero id means next automatic ID. Caller must hold the lock.
+func (d *Dataset) append(id uint32, data, meta, vector Unit) (uint32, error) {
+	newID := id
+	if newID == 0 {
+		if d.lastID == math.MaxUint32 {
+			return 0, fmt.Errorf("ID space exhausted")
+		}
+		newID = d.lastID + 1
+	} else if _, ok := d.index[newID]; ok {
+		return 0, fmt.Errorf("chunk with id %d already exists", newID)
+	}
+
 	// Expand capacity if needed
 	if d.header.indexLen >= d.header.indexCap {
+		d.logger.Debug("expanding index capacity", "path", d.path, "from", d.header.indexCap, "to", d.header.indexCap*2)
 		if err := d.ChangeIndexCap(int(d.header.indexCap)*2, false); err != nil {
 			return 0, fmt.Errorf("failed to expand index capacity: %w", err)
 		}
@@ -342,7 +529,6 @@ func (d *Dataset) Append(data, meta, vector Unit) (uint32, error) {
 	}
 
 	// Create index record
-	newID := d.lastID + 1
 	idx := index{
 		ID:         newID,
 		Flags:      0,
@@ -352,6 +538,7 @@ func (d *Dataset) Append(data, meta, vector Unit) (uint32, error) {
 		Position:   chunkPos,
 		Size:       uint64(cr.size()),
 		Date:       uint64(time.Now().Unix()),
+		slot:       d.header.indexLen,
 	}
 
 	// Write index record
@@ -373,7 +560,8 @@ func (d *Dataset) Append(data, meta, vector Unit) (uint32, error) {
 
 	// Update in-memory state
 	d.index[newID] = idx
-	d.lastID = newID
+	d.lastID = max(d.lastID, newID)
+	d.trackHash(newID, dataBlob)
 
 	return newID, nil
 }
@@ -382,6 +570,12 @@ func (d *Dataset) Append(data, meta, vector Unit) (uint32, error) {
 // Pass specific fields to read selectively (e.g., FieldData, FieldMeta).
 // Non-selected fields will be nil in the returned Chunk.
 func (d *Dataset) Read(id uint32, fields ...Field) (*Chunk, error) {
+	for _, h := range d.hookList() {
+		if err := h.BeforeRead(id); err != nil {
+			return nil, fmt.Errorf("read rejected by hook: %w", err)
+		}
+	}
+
 	d.Lock()
 	defer d.Unlock()
 
@@ -390,20 +584,33 @@ func (d *Dataset) Read(id uint32, fields ...Field) (*Chunk, error) {
 		return nil, fmt.Errorf("chunk with id %d not found", id)
 	}
 
-	// Determine which fields to read
-	readData, readMeta, readVector := true, true, true
+	if d.cache != nil {
+		if cr, ok := d.cache.get(id); ok {
+			return idx.chunk(cr, newFieldSet(fields)), nil
+		}
+	}
+
+	// Read only selected blobs so that unselected payload is not loaded
 	if len(fields) > 0 {
-		readData, readMeta, readVector = false, false, false
-		for _, f := range fields {
-			switch f {
-			case FieldData:
-				readData = true
-			case FieldMeta:
-				readMeta = true
-			case FieldVector:
-				readVector = true
-			}
+		chunk := &Chunk{
+			ID:    id,
+			Date:  idx.Date,
+			Flags: idx.Flags,
+		}
+		if err := d.readChunkFields(chunk, &idx, fields); err != nil {
+			return nil, err
+		}
+		fs := newFieldSet(fields)
+		if fs.data && chunk.Data == nil {
+			chunk.Data = NewByteUnit(nil, idx.DataDesc)
 		}
+		if fs.meta && chunk.Meta == nil {
+			chunk.Meta = NewByteUnit(nil, idx.MetaDesc)
+		}
+		if fs.vector && chunk.Vector == nil {
+			chunk.Vector = NewByteUnit(nil, idx.VectorDesc)
+		}
+		return chunk, nil
 	}
 
 	// Seek to chunk position
@@ -417,24 +624,11 @@ func (d *Dataset) Read(id uint32, fields ...Field) (*Chunk, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	// Build Chunk with selected fields as RawUnit
-	chunk := &Chunk{
-		ID:    id,
-		Date:  idx.Date,
-		Flags: idx.Flags,
-	}
-	if readData {
-		chunk.Data = NewByteUnit(cr.Data, idx.DataDesc)
-	}
-	if readMeta {
-		chunk.Meta = NewByteUnit(cr.Meta, idx.MetaDesc)
-	}
-	if readVector {
-		chunk.Vector = NewByteUnit(cr.Vector, idx.VectorDesc)
+	if d.cache != nil {
+		d.cache.put(id, cr.blob())
 	}
 
-	return chunk, nil
+	return idx.chunk(cr, newFieldSet(nil)), nil
 }
 
 // Delete marks a chunk as deleted by ID.
@@ -444,23 +638,35 @@ func (d *Dataset) Delete(id uint32) bool {
 	d.Lock()
 	defer d.Unlock()
 
-	idx, ok := d.index[id]
-	if !ok {
+	if _, ok := d.index[id]; !ok {
 		return false
 	}
+	if err := d.markDeleted(id); err != nil {
+		return false
+	}
+	if err := d.f.Sync(); err != nil {
+		return false
+	}
+	return true
+}
 
+// markDeleted writes the deleted flag of an existing chunk without syncing the file
+// and removes the chunk from memory. Caller must hold the lock.
+func (d *Dataset) markDeleted(id uint32) error {
+	idx := d.index[id]
 	idx.setDeleted()
 	idx.Date = uint64(time.Now().Unix())
 
 	if err := idx.writeAt(d.f, d.header.size()); err != nil {
-		return false
-	}
-	if err := d.f.Sync(); err != nil {
-		return false
+		return fmt.Errorf("failed to write index record: %w", err)
 	}
 
 	delete(d.index, id)
-	return true
+	d.untrackHash(id)
+	if d.cache != nil {
+		d.cache.remove(id)
+	}
+	return nil
 }
 
 // Update modifies an existing chunk by ID.
@@ -470,6 +676,20 @@ func (d *Dataset) Update(id uint32, data, meta, vector Unit) error {
 	d.Lock()
 	defer d.Unlock()
 
+	if err := d.update(id, data, meta, vector); err != nil {
+		return err
+	}
+
+	// Sync file to disk
+	if err := d.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	return nil
+}
+
+// update writes the modified chunk and its index record without syncing the file.
+// Caller must hold the lock.
+func (d *Dataset) update(id uint32, data, meta, vector Unit) error {
 	idx, ok := d.index[id]
 	if !ok {
 		return fmt.Errorf("chunk with id %d not found", id)
@@ -552,13 +772,15 @@ func (d *Dataset) Update(id uint32, data, meta, vector Unit) error {
 		return fmt.Errorf("failed to write index record: %w", err)
 	}
 
-	// Sync file to disk
-	if err := d.f.Sync(); err != nil {
-		return fmt.Errorf("failed to sync file: %w", err)
-	}
-
 	// Update in-memory index
 	d.index[id] = idx
+	if d.cache != nil {
+		d.cache.remove(id)
+	}
+	if data != nil {
+		d.untrackHash(id)
+		d.trackHash(id, newData)
+	}
 
 	return nil
 }
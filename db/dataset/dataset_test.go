@@ -1,7 +1,17 @@
 package dataset
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"math"
+	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/webzak/mindstore/internal/testutil/assert"
@@ -118,3 +128,1168 @@ func TestOptimize(t *testing.T) {
 	_, err = ds.Read(id2)
 	assert.NotNilError(t, err)
 }
+
+func TestStats(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	ds.Append(NewByteUnit([]byte("a"), 1), nil, nil)
+	id, _ := ds.Append(NewByteUnit([]byte("bb"), 1), nil, nil)
+	ds.Append(NewByteUnit([]byte("ccc"), 2), nil, nil)
+	ds.Delete(id)
+
+	s, err := ds.Stats()
+	assert.NilError(t, err)
+	assert.Equal(t, 2, s.Records)
+	assert.Equal(t, 1, s.Deleted)
+	assert.Equal(t, uint64(17), s.MinChunkSize)
+	assert.Equal(t, uint64(19), s.MaxChunkSize)
+	assert.Equal(t, 1, s.DataDescriptors[1])
+	assert.Equal(t, 1, s.DataDescriptors[2])
+	assert.Equal(t, int64(36), s.LiveBytes)
+	assert.Equal(t, int64(18), s.DeadBytes)
+	assert.Equal(t, true, s.OptimizeRecommended)
+
+	err = ds.Optimize()
+	assert.NilError(t, err)
+	s, err = ds.Stats()
+	assert.NilError(t, err)
+	assert.Equal(t, int64(0), s.DeadBytes)
+	assert.Equal(t, int64(0), s.UnusedIndexBytes)
+	assert.Equal(t, false, s.OptimizeRecommended)
+}
+
+func TestSetLogger(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	var buf bytes.Buffer
+	ds.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	ds.Append(NewByteUnit([]byte("a"), 0), nil, nil)
+	err := ds.Optimize()
+	assert.NilError(t, err)
+	assert.Equal(t, true, strings.Contains(buf.String(), "dataset optimized"))
+}
+
+func TestFlags(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	names, err := NewFlagNames("reviewed", "pinned")
+	assert.NilError(t, err)
+	pinned, err := names.Flag("pinned")
+	assert.NilError(t, err)
+
+	ds.Append(NewByteUnit([]byte("a"), 0), nil, nil)
+	id, _ := ds.Append(NewByteUnit([]byte("b"), 0), nil, nil)
+
+	err = ds.SetFlags(id, pinned)
+	assert.NilError(t, err)
+
+	var ids []uint32
+	for c, err := range ds.List().Filter(ByFlags(pinned)).Iter() {
+		assert.NilError(t, err)
+		ids = append(ids, c.ID)
+	}
+	assert.DeepEqual(t, []uint32{id}, ids)
+
+	c, err := ds.Read(id)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"pinned"}, names.Names(c.Flags))
+
+	err = ds.ClearFlags(id, pinned)
+	assert.NilError(t, err)
+	c, err = ds.Read(id)
+	assert.NilError(t, err)
+	assert.Equal(t, uint8(0), c.Flags)
+
+	err = ds.SetFlags(id, FlagDeleted)
+	assert.NotNilError(t, err)
+}
+
+type testHook struct {
+	NopHook
+	appended []uint32
+}
+
+func (h *testHook) BeforeAppend(c *Chunk) error {
+	if len(c.Data.Blob()) == 0 {
+		return errors.New("empty data")
+	}
+	c.Meta = NewByteUnit([]byte("auto"), 0)
+	return nil
+}
+
+func (h *testHook) AfterAppend(c *Chunk) {
+	h.appended = append(h.appended, c.ID)
+}
+
+func TestHooks(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	h := &testHook{}
+	ds.AddHook(h)
+
+	id, err := ds.Append(NewByteUnit([]byte("a"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []uint32{id}, h.appended)
+
+	c, err := ds.Read(id)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("auto"), c.Meta.Blob())
+
+	_, err = ds.Append(NewByteUnit(nil, 0), nil, nil)
+	assert.NotNilError(t, err)
+}
+
+func TestAppendUnique(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	id1, err := ds.Append(NewByteUnit([]byte("same"), 0), nil, nil)
+	assert.NilError(t, err)
+
+	id, existed, err := ds.AppendUnique(NewByteUnit([]byte("same"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, true, existed)
+	assert.Equal(t, id1, id)
+
+	id2, existed, err := ds.AppendUnique(NewByteUnit([]byte("other"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, false, existed)
+
+	// Updated content is tracked
+	err = ds.Update(id2, NewByteUnit([]byte("changed"), 0), nil, nil)
+	assert.NilError(t, err)
+	id, existed, err = ds.AppendUnique(NewByteUnit([]byte("changed"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, true, existed)
+	assert.Equal(t, id2, id)
+
+	// Deleted content can be appended again
+	ds.Delete(id1)
+	id, existed, err = ds.AppendUnique(NewByteUnit([]byte("same"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, false, existed)
+	assert.Equal(t, true, id != id1)
+
+	// Remaining chunk with the same content is found after delete
+	dup, err := ds.Append(NewByteUnit([]byte("same"), 0), nil, nil)
+	assert.NilError(t, err)
+	ds.Delete(id)
+	got, existed, err := ds.AppendUnique(NewByteUnit([]byte("same"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, true, existed)
+	assert.Equal(t, dup, got)
+}
+
+// trimHook normalizes data before append
+type trimHook struct {
+	NopHook
+}
+
+func (trimHook) BeforeAppend(c *Chunk) error {
+	c.Data = NewByteUnit(bytes.TrimSpace(c.Data.Blob()), c.Data.Descriptor())
+	return nil
+}
+
+func TestAppendUniqueSavedHashes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ds")
+	ds, err := NewDataset(path, 0, nil, 10)
+	assert.NilError(t, err)
+	idA, err := ds.Append(NewByteUnit([]byte("a"), 0), nil, nil)
+	assert.NilError(t, err)
+	idB, err := ds.Append(NewByteUnit([]byte("b"), 0), nil, nil)
+	assert.NilError(t, err)
+	_, _, err = ds.AppendUnique(NewByteUnit([]byte("c"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.NilError(t, ds.Close())
+	_, err = os.Stat(hashFilePath(path))
+	assert.NilError(t, err)
+
+	// Saved hash is used instead of reading data: overwrite data of "a" in place
+	ds, err = OpenDataset(path)
+	assert.NilError(t, err)
+	idx := ds.index[idA]
+	_, err = ds.f.WriteAt([]byte("z"), ds.header.dataSpacePos()+int64(idx.Position)+sizeChunkHeader)
+	assert.NilError(t, err)
+	id, existed, err := ds.AppendUnique(NewByteUnit([]byte("a"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, true, existed)
+	assert.Equal(t, idA, id)
+	assert.NilError(t, ds.Close())
+
+	// Chunk changed while hashes were not tracked is hashed again
+	ds, err = OpenDataset(path)
+	assert.NilError(t, err)
+	assert.NilError(t, ds.Update(idB, NewByteUnit([]byte("e"), 0), nil, nil))
+	assert.NilError(t, ds.Close())
+	ds, err = OpenDataset(path)
+	assert.NilError(t, err)
+	id, existed, err = ds.AppendUnique(NewByteUnit([]byte("e"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, true, existed)
+	assert.Equal(t, idB, id)
+	_, existed, err = ds.AppendUnique(NewByteUnit([]byte("b"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, false, existed)
+	assert.NilError(t, ds.Close())
+
+	// Malformed hash file is ignored
+	assert.NilError(t, os.WriteFile(hashFilePath(path), []byte("garbage"), 0o644))
+	ds, err = OpenDataset(path)
+	assert.NilError(t, err)
+	defer ds.Close()
+	id, existed, err = ds.AppendUnique(NewByteUnit([]byte("e"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, true, existed)
+	assert.Equal(t, idB, id)
+}
+
+func TestAppendUniqueHooks(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+	ds.AddHook(trimHook{})
+
+	id, existed, err := ds.AppendUnique(NewByteUnit([]byte(" text "), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, false, existed)
+
+	// Lookup uses data as changed by hooks
+	got, existed, err := ds.AppendUnique(NewByteUnit([]byte("text"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, true, existed)
+	assert.Equal(t, id, got)
+}
+
+func TestListAfterLimit(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		ds.Append(NewByteUnit([]byte(s), 0), nil, nil)
+	}
+	ds.Delete(3)
+
+	// Page through with continuation token
+	var pages [][]uint32
+	var after uint32
+	for {
+		var page []uint32
+		for c, err := range ds.List().After(after).Limit(2).Iter() {
+			assert.NilError(t, err)
+			page = append(page, c.ID)
+		}
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, page)
+		after = page[len(page)-1]
+	}
+	assert.DeepEqual(t, [][]uint32{{1, 2}, {4, 5}}, pages)
+}
+
+func TestCount(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	ds.Append(NewByteUnit([]byte("a"), 0), NewByteUnit([]byte("x"), 0), nil)
+	ds.Append(NewByteUnit([]byte("b"), 0), NewByteUnit([]byte("y"), 0), nil)
+	ds.Append(NewByteUnit([]byte("c"), 0), NewByteUnit([]byte("x"), 0), nil)
+
+	n, err := ds.List().Filter(ByIDs(1, 3)).Count()
+	assert.NilError(t, err)
+	assert.Equal(t, 2, n)
+
+	counts, err := ds.List().Load(FieldMeta).CountBy(func(c *Chunk) (string, error) {
+		return string(c.Meta.Blob()), nil
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]int{"x": 2, "y": 1}, counts)
+}
+
+func TestReadMany(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	for _, s := range []string{"a", "b", "c", "d"} {
+		ds.Append(NewByteUnit([]byte(s), 0), NewByteUnit([]byte("m"+s), 0), nil)
+	}
+	// Move chunk 2 to the end of file so reads are not contiguous
+	err := ds.Update(2, NewByteUnit([]byte("B"), 0), nil, nil)
+	assert.NilError(t, err)
+
+	chunks, err := ds.ReadMany([]uint32{4, 2, 1, 4}, FieldData)
+	assert.NilError(t, err)
+	var data []string
+	for _, c := range chunks {
+		data = append(data, string(c.Data.Blob()))
+		assert.Equal(t, true, c.Meta == nil)
+	}
+	assert.DeepEqual(t, []string{"d", "B", "a", "d"}, data)
+
+	// Repeated ids get independent blobs
+	chunks[0].Data.Blob()[0] = 'x'
+	assert.DeepEqual(t, []byte("d"), chunks[3].Data.Blob())
+
+	_, err = ds.ReadMany([]uint32{1, 9})
+	assert.NotNilError(t, err)
+}
+
+func TestReadFields(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	id, err := ds.Append(NewByteUnit([]byte("data"), 1), NewByteUnit([]byte("meta"), 2), NewByteUnit([]byte("vec"), 3))
+	assert.NilError(t, err)
+
+	c, err := ds.Read(id, FieldMeta)
+	assert.NilError(t, err)
+	assert.Equal(t, true, c.Data == nil)
+	assert.Equal(t, true, c.Vector == nil)
+	assert.DeepEqual(t, []byte("meta"), c.Meta.Blob())
+	assert.Equal(t, uint8(2), c.Meta.Descriptor())
+
+	c, err = ds.Read(id, FieldVector)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("vec"), c.Vector.Blob())
+}
+
+func TestCache(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+	ds.SetCache(1 << 10)
+
+	id, _ := ds.Append(NewByteUnit([]byte("one"), 0), nil, nil)
+
+	c, err := ds.Read(id)
+	assert.NilError(t, err)
+	// Mutating returned data must not affect cached copy
+	c.Data.Blob()[0] = 'X'
+
+	c, err = ds.Read(id)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("one"), c.Data.Blob())
+
+	err = ds.Update(id, NewByteUnit([]byte("two"), 0), nil, nil)
+	assert.NilError(t, err)
+	c, err = ds.Read(id)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("two"), c.Data.Blob())
+}
+
+func TestChunkCacheEviction(t *testing.T) {
+	// 20 byte chunk record: 16 bytes of sizes and 4 bytes of data
+	blob := func() []byte {
+		return (&chunkRecord{dataSize: 4, Data: []byte("data")}).blob()
+	}
+	c := newChunkCache(40)
+	c.put(1, blob())
+	c.put(2, blob())
+	c.get(1)
+	c.put(3, blob())
+
+	_, ok := c.get(2)
+	assert.Equal(t, false, ok)
+	_, ok = c.get(1)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, int64(40), c.bytes)
+}
+
+func BenchmarkAppend(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "bench.ds")
+	ds, err := NewDataset(path, 0, nil, 1024)
+	assert.NilError(b, err)
+	defer ds.Close()
+
+	data := NewByteUnit(make([]byte, 4096), 0)
+	vector := NewByteUnit(make([]byte, 1536), 0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ds.Append(data, nil, vector); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestReadNoCopy(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	id, _ := ds.Append(NewByteUnit([]byte("one"), 0), NewByteUnit([]byte("m"), 0), nil)
+
+	var data string
+	err := ds.ReadNoCopy(id, func(c *Chunk) error {
+		data = string(c.Data.Blob())
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, "one", data)
+
+	// Served from cache
+	ds.SetCache(1 << 10)
+	ds.Read(id)
+	err = ds.ReadNoCopy(id, func(c *Chunk) error {
+		data = string(c.Meta.Blob())
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, "m", data)
+
+	err = ds.ReadNoCopy(id+1, func(c *Chunk) error { return nil })
+	assert.NotNilError(t, err)
+}
+
+func BenchmarkRead(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "bench.ds")
+	ds, err := NewDataset(path, 0, nil, 16)
+	assert.NilError(b, err)
+	defer ds.Close()
+	id, err := ds.Append(NewByteUnit(make([]byte, 4096), 0), nil, NewByteUnit(make([]byte, 1536), 0))
+	assert.NilError(b, err)
+
+	b.Run("Read", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ds.Read(id); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ReadNoCopy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := ds.ReadNoCopy(id, func(c *Chunk) error { return nil }); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestOptimizeAppendReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ds")
+	ds, err := NewDataset(path, 0, nil, 10)
+	assert.NilError(t, err)
+
+	ds.Append(NewByteUnit([]byte("one"), 0), nil, nil)
+	id2, _ := ds.Append(NewByteUnit([]byte("two"), 0), nil, nil)
+	ds.Append(NewByteUnit([]byte("three"), 0), nil, nil)
+	ds.Delete(id2)
+	assert.NilError(t, ds.Optimize())
+
+	id4, err := ds.Append(NewByteUnit([]byte("four"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.NilError(t, ds.Update(3, NewByteUnit([]byte("THREE"), 0), nil, nil))
+	assert.NilError(t, ds.Close())
+
+	ds, err = OpenDataset(path)
+	assert.NilError(t, err)
+	defer ds.Close()
+
+	c, err := ds.Read(id4)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("four"), c.Data.Blob())
+	c, err = ds.Read(3)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("THREE"), c.Data.Blob())
+}
+
+// Optimize packs index records, so records must be written at their slot
+// and not at ID-1
+func TestIndexSlotAfterOptimize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ds")
+	ds, err := NewDataset(path, 0, nil, 10)
+	assert.NilError(t, err)
+	for _, s := range []string{"one", "two", "three", "four", "five"} {
+		_, err := ds.Append(NewByteUnit([]byte(s), 0), nil, nil)
+		assert.NilError(t, err)
+	}
+	ds.Delete(2)
+	ds.Delete(4)
+	assert.NilError(t, ds.Optimize())
+
+	// slots are now 1:0, 3:1, 5:2
+	assert.NilError(t, ds.Update(5, NewByteUnit([]byte("FIVE"), 0), nil, nil))
+	assert.Equal(t, true, ds.Delete(3))
+	assert.NilError(t, ds.SetFlags(1, IndexFlag(2)))
+	assert.NilError(t, ds.Close())
+
+	ds, err = OpenDataset(path)
+	assert.NilError(t, err)
+	defer ds.Close()
+
+	violations, err := ds.Validate()
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(violations))
+
+	var ids []uint32
+	for c, err := range ds.List().Iter() {
+		assert.NilError(t, err)
+		ids = append(ids, c.ID)
+	}
+	assert.DeepEqual(t, []uint32{1, 5}, ids)
+	c, err := ds.Read(1)
+	assert.NilError(t, err)
+	assert.Equal(t, uint8(2), c.Flags)
+	c, err = ds.Read(5)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("FIVE"), c.Data.Blob())
+}
+
+func TestRepairTornAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ds")
+	ds, err := NewDataset(path, 0, nil, 10)
+	assert.NilError(t, err)
+	ds.Append(NewByteUnit([]byte("one"), 0), nil, nil)
+	ds.Append(NewByteUnit([]byte("two"), 0), nil, nil)
+	assert.NilError(t, ds.Close())
+
+	// Cut the last chunk in half
+	fi, err := os.Stat(path)
+	assert.NilError(t, err)
+	assert.NilError(t, os.Truncate(path, fi.Size()-5))
+
+	report, err := Repair(path)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, report.DroppedRecords)
+	assert.Equal(t, int64(14), report.TruncatedBytes)
+
+	ds, err = OpenDataset(path)
+	assert.NilError(t, err)
+	defer ds.Close()
+
+	n, err := ds.List().Count()
+	assert.NilError(t, err)
+	assert.Equal(t, 1, n)
+
+	id, err := ds.Append(NewByteUnit([]byte("again"), 0), nil, nil)
+	assert.NilError(t, err)
+	c, err := ds.Read(id)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("again"), c.Data.Blob())
+}
+
+func TestRepairTornUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ds")
+	ds, err := NewDataset(path, 0, nil, 10)
+	assert.NilError(t, err)
+	ds.Append(NewByteUnit([]byte("one"), 0), nil, nil)
+	ds.Append(NewByteUnit([]byte("two"), 0), nil, nil)
+	assert.NilError(t, ds.Update(1, NewByteUnit([]byte("ONE"), 0), nil, nil))
+	assert.NilError(t, ds.Close())
+
+	// Lose the updated chunk
+	fi, err := os.Stat(path)
+	assert.NilError(t, err)
+	assert.NilError(t, os.Truncate(path, fi.Size()-1))
+
+	ds, err = OpenDataset(path)
+	assert.NilError(t, err)
+	defer ds.Close()
+
+	_, err = ds.Read(1)
+	assert.NotNilError(t, err)
+	c, err := ds.Read(2)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("two"), c.Data.Blob())
+}
+
+func TestRepairScope(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ds")
+	ds, err := NewDataset(path, 0, nil, 10)
+	assert.NilError(t, err)
+	for _, s := range []string{"one", "two", "three"} {
+		_, err := ds.Append(NewByteUnit([]byte(s), 0), nil, nil)
+		assert.NilError(t, err)
+	}
+	dataPos := ds.header.dataSpacePos()
+	assert.NilError(t, ds.Close())
+
+	// Damage the first chunk, which is before the last durable point
+	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+	assert.NilError(t, err)
+	_, err = f.WriteAt([]byte{0xff, 0xff}, dataPos)
+	assert.NilError(t, err)
+	assert.NilError(t, f.Close())
+
+	// Open checks the tail only and leaves the record to fail on read
+	ds, err = OpenDataset(path)
+	assert.NilError(t, err)
+	_, err = ds.Read(1)
+	assert.Equal(t, true, errors.Is(err, ErrCorrupted))
+	c, err := ds.Read(3)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("three"), c.Data.Blob())
+	assert.NilError(t, ds.Close())
+
+	report, err := Repair(path)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, report.DeletedRecords)
+	assert.Equal(t, 0, report.DroppedRecords)
+}
+
+func TestOpenLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ds")
+	ds, err := NewDataset(path, 0, nil, 10)
+	assert.NilError(t, err)
+
+	_, err = OpenDataset(path)
+	assert.Equal(t, true, errors.Is(err, ErrLocked))
+	_, err = NewDataset(path, 0, nil, 10)
+	assert.Equal(t, true, errors.Is(err, ErrLocked))
+	_, err = Repair(path)
+	assert.Equal(t, true, errors.Is(err, ErrLocked))
+
+	// Lock follows the file through replacement
+	assert.NilError(t, ds.Optimize())
+	_, err = OpenDataset(path)
+	assert.Equal(t, true, errors.Is(err, ErrLocked))
+
+	assert.NilError(t, ds.Close())
+	ds, err = OpenDataset(path)
+	assert.NilError(t, err)
+	assert.NilError(t, ds.Close())
+
+	// No lock file is left for a missing dataset
+	missing := filepath.Join(t.TempDir(), "missing.ds")
+	_, err = OpenDataset(missing)
+	assert.NotNilError(t, err)
+	_, err = os.Stat(missing + ".lock")
+	assert.Equal(t, true, os.IsNotExist(err))
+}
+
+func TestReplaceFileRenameFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("open files cannot be removed on windows")
+	}
+	path := filepath.Join(t.TempDir(), "test.ds")
+	ds, err := NewDataset(path, 0, nil, 10)
+	assert.NilError(t, err)
+	_, err = ds.Append(NewByteUnit([]byte("one"), 0), nil, nil)
+	assert.NilError(t, err)
+
+	// A non-empty directory at path makes both rename and reopen fail
+	assert.NilError(t, os.Remove(path))
+	assert.NilError(t, os.Mkdir(path, 0755))
+	assert.NilError(t, os.WriteFile(filepath.Join(path, "x"), nil, 0644))
+
+	err = ds.Optimize()
+	assert.NotNilError(t, err)
+	_, err = os.Stat(path + ".tmp")
+	assert.Equal(t, true, os.IsNotExist(err))
+
+	// Closed handle is not kept, operations fail instead
+	assert.Equal(t, true, ds.f == nil)
+	_, err = ds.Append(NewByteUnit([]byte("two"), 0), nil, nil)
+	assert.NotNilError(t, err)
+	ds.Close()
+}
+
+func TestAppendAtAndReserve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ds")
+	ds, err := NewDataset(path, 0, nil, 10)
+	assert.NilError(t, err)
+
+	first, err := ds.ReserveIDs(3)
+	assert.NilError(t, err)
+	assert.Equal(t, uint32(1), first)
+
+	id, err := ds.Append(NewByteUnit([]byte("auto"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, uint32(4), id)
+
+	err = ds.AppendAt(2, NewByteUnit([]byte("two"), 0), nil, nil)
+	assert.NilError(t, err)
+	err = ds.AppendAt(2, NewByteUnit([]byte("again"), 0), nil, nil)
+	assert.NotNilError(t, err)
+
+	err = ds.AppendAt(10, NewByteUnit([]byte("ten"), 0), nil, nil)
+	assert.NilError(t, err)
+	id, err = ds.Append(NewByteUnit([]byte("next"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, uint32(11), id)
+	assert.NilError(t, ds.Close())
+
+	ds, err = OpenDataset(path)
+	assert.NilError(t, err)
+	defer ds.Close()
+
+	var ids []uint32
+	for c, err := range ds.List().Iter() {
+		assert.NilError(t, err)
+		ids = append(ids, c.ID)
+	}
+	assert.DeepEqual(t, []uint32{2, 4, 10, 11}, ids)
+
+	c, err := ds.Read(2)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("two"), c.Data.Blob())
+}
+
+func TestDescriptors(t *testing.T) {
+	r := NewDescriptors()
+	assert.NilError(t, r.Register(DescriptorInfo{Descriptor: 1, MIME: "text/plain", Name: "Text"}))
+	assert.NilError(t, r.Register(DescriptorInfo{Descriptor: 2, MIME: "image/*", Name: "Image"}))
+	assert.NotNilError(t, r.Register(DescriptorInfo{Descriptor: 1, MIME: "text/html"}))
+	assert.NotNilError(t, r.Register(DescriptorInfo{Descriptor: 3, MIME: "TEXT/PLAIN"}))
+
+	info, ok := r.Sniff([]byte("hello world"))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, uint8(1), info.Descriptor)
+
+	info, ok = r.Sniff([]byte("\x89PNG\x0D\x0A\x1A\x0A"))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "Image", info.Name)
+
+	info, ok = r.Lookup(2)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "image/*", info.MIME)
+
+	_, ok = r.ByMIME("application/pdf")
+	assert.Equal(t, false, ok)
+}
+
+func TestValidate(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	ds.Append(NewByteUnit([]byte("one"), 0), nil, nil)
+	id, _ := ds.Append(NewByteUnit([]byte("two"), 0), nil, nil)
+
+	v, err := ds.Validate()
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(v))
+
+	// Corrupt the size of the second chunk in place
+	idx := ds.index[id]
+	idx.Size++
+	assert.NilError(t, idx.writeAt(ds.f, ds.header.size()))
+
+	v, err = ds.Validate()
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(v))
+	assert.Equal(t, ViolationOutOfBounds, v[0].Kind)
+	assert.Equal(t, id, v[0].ID)
+}
+
+func TestListContext(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	ds.Append(NewByteUnit([]byte("a"), 0), nil, nil)
+	ds.Append(NewByteUnit([]byte("b"), 0), nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	count := 0
+	var lastErr error
+	for _, err := range ds.List().Context(ctx).Iter() {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		count++
+		cancel()
+	}
+	assert.Equal(t, 1, count)
+	assert.Equal(t, true, errors.Is(lastErr, context.Canceled))
+}
+
+func TestMemoryStats(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	m := ds.MemoryStats()
+	assert.Equal(t, int64(0), m.Total)
+
+	ds.SetCache(1 << 10)
+	id, _ := ds.Append(NewByteUnit([]byte("one"), 0), nil, nil)
+	ds.Read(id)
+
+	m = ds.MemoryStats()
+	assert.Equal(t, true, m.Index > 0)
+	assert.Equal(t, true, m.Cache > 19)
+	assert.Equal(t, int64(0), m.Hashes)
+	assert.Equal(t, m.Index+m.Cache, m.Total)
+}
+
+func TestOpenDatasetCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ds")
+	ds, err := NewDataset(path, 0, nil, 2)
+	assert.NilError(t, err)
+	_, err = ds.Append(NewByteUnit([]byte("one"), 0), nil, nil)
+	assert.NilError(t, err)
+	assert.NilError(t, ds.Close())
+
+	orig, err := os.ReadFile(path)
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name  string
+		patch func(buf []byte)
+	}{
+		// config size, index capacity and length are at offsets 8, 12 and 16 for empty config
+		{"huge config size", func(buf []byte) { binary.LittleEndian.PutUint32(buf[8:], math.MaxUint32) }},
+		{"huge index capacity", func(buf []byte) { binary.LittleEndian.PutUint32(buf[12:], math.MaxUint32) }},
+		{"index length over capacity", func(buf []byte) { binary.LittleEndian.PutUint32(buf[16:], 3) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Clone(orig)
+			tt.patch(buf)
+			assert.NilError(t, os.WriteFile(path, buf, 0644))
+			_, err := OpenDataset(path)
+			assert.Equal(t, true, errors.Is(err, ErrCorrupted))
+		})
+	}
+}
+
+func TestOpenDatasetCorruptedMiddleRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ds")
+	ds, err := NewDataset(path, 0, nil, 2)
+	assert.NilError(t, err)
+	for _, s := range []string{"one", "two"} {
+		_, err = ds.Append(NewByteUnit([]byte(s), 0), nil, nil)
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, ds.Close())
+
+	orig, err := os.ReadFile(path)
+	assert.NilError(t, err)
+
+	// the first index record starts at 20 for empty config, its size at +16;
+	// a corrupted size keeps the record below the last durable point
+	tests := []struct {
+		name  string
+		patch func(buf []byte)
+	}{
+		{"huge size", func(buf []byte) { binary.LittleEndian.PutUint64(buf[36:], 1<<40) }},
+		{"size out of int range", func(buf []byte) { binary.LittleEndian.PutUint64(buf[36:], 1<<62) }},
+		{"size below chunk header", func(buf []byte) { binary.LittleEndian.PutUint64(buf[36:], 1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Clone(orig)
+			tt.patch(buf)
+			assert.NilError(t, os.WriteFile(path, buf, 0644))
+			_, err := OpenDataset(path)
+			assert.Equal(t, true, errors.Is(err, ErrCorrupted))
+
+			// Repair marks the record deleted and the rest stays readable
+			report, err := Repair(path)
+			assert.NilError(t, err)
+			assert.Equal(t, 1, report.DeletedRecords)
+			ds, err := OpenDataset(path)
+			assert.NilError(t, err)
+			defer ds.Close()
+			c, err := ds.Read(2)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, []byte("two"), c.Data.Blob())
+		})
+	}
+}
+
+func TestParseChunkCorrupted(t *testing.T) {
+	buf := (&chunkRecord{dataSize: 3, Data: []byte("one")}).blob()
+	_, err := parseChunk(buf)
+	assert.NilError(t, err)
+
+	// data size overflowing total size
+	binary.LittleEndian.PutUint64(buf, math.MaxUint64-10)
+	_, err = parseChunk(buf)
+	assert.Equal(t, true, errors.Is(err, ErrCorrupted))
+
+	_, err = parseChunk(buf[:10])
+	assert.Equal(t, true, errors.Is(err, ErrCorrupted))
+}
+
+func FuzzParseChunk(f *testing.F) {
+	f.Add((&chunkRecord{dataSize: 3, metaSize: 2, Data: []byte("one"), Meta: []byte("{}")}).blob())
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		cr, err := parseChunk(buf)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(cr.blob(), buf) {
+			t.Errorf("parsed chunk does not encode back to input")
+		}
+	})
+}
+
+func FuzzOpenDataset(f *testing.F) {
+	path := filepath.Join(f.TempDir(), "seed.ds")
+	ds, err := NewDataset(path, 1, []byte("cfg"), 4)
+	assert.NilError(f, err)
+	ds.Append(NewByteUnit([]byte("one"), 1), NewByteUnit([]byte("meta"), 2), nil)
+	ds.Append(NewByteUnit([]byte("two"), 1), nil, NewByteUnit([]byte{1, 2, 3, 4}, 3))
+	ds.Close()
+	seed, err := os.ReadFile(path)
+	assert.NilError(f, err)
+	f.Add(seed)
+
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		path := filepath.Join(t.TempDir(), "fuzz.ds")
+		if err := os.WriteFile(path, buf, 0644); err != nil {
+			t.Fatal(err)
+		}
+		ds, err := OpenDataset(path)
+		if err != nil {
+			return
+		}
+		defer ds.Close()
+		// iterator holds the lock, so reads by ID are done after it
+		var ids []uint32
+		for c, err := range ds.List().Load(FieldData, FieldMeta, FieldVector).Iter() {
+			if err == nil {
+				ids = append(ids, c.ID)
+			}
+		}
+		for _, id := range ids {
+			ds.Read(id, FieldMeta)
+			ds.Read(id)
+		}
+		ds.Validate()
+	})
+}
+
+func TestVectors(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	// IDs 2, 3, 5, 6, 8 and 9 have vectors
+	for i := range 10 {
+		var vec Unit
+		if i%3 != 0 {
+			vec = NewByteUnit([]byte{byte(i), 0, 0, 0}, 1)
+		}
+		_, err := ds.Append(NewByteUnit([]byte("data"), 0), NewByteUnit([]byte("meta"), 0), vec)
+		assert.NilError(t, err)
+	}
+
+	var got []uint32
+	for c, err := range ds.Vectors(2) {
+		assert.NilError(t, err)
+		assert.Equal(t, true, c.Data == nil && c.Meta == nil)
+		// vector is found past data and meta
+		assert.DeepEqual(t, []byte{byte(c.ID - 1), 0, 0, 0}, c.Vector.Blob())
+		assert.Equal(t, uint8(1), c.Vector.Descriptor())
+		got = append(got, c.ID)
+		// lock is not held while yielding, the next chunk is deleted
+		if c.ID == 2 {
+			assert.Equal(t, true, ds.Delete(3))
+		}
+	}
+	assert.DeepEqual(t, []uint32{2, 5, 6, 8, 9}, got)
+}
+
+func TestListSortBy(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	// meta holds a single byte rank, descriptor 1 marks the source of interest
+	for i, rank := range []byte{3, 1, 4, 1, 5, 9} {
+		desc := uint8(1)
+		if i == 2 {
+			desc = 2
+		}
+		_, err := ds.Append(NewByteUnit([]byte("x"), desc), NewByteUnit([]byte{rank}, 0), nil)
+		assert.NilError(t, err)
+	}
+
+	byDesc := func(c *Chunk) (bool, error) { return c.Data.Descriptor() == 1, nil }
+	byRank := func(a, b *Chunk) int { return int(a.Meta.Blob()[0]) - int(b.Meta.Blob()[0]) }
+
+	chunks, err := ds.List().Filter(byDesc).Load(FieldMeta).SortBy(byRank).Limit(4).All()
+	assert.NilError(t, err)
+	var ids []uint32
+	for _, c := range chunks {
+		ids = append(ids, c.ID)
+	}
+	// equal ranks keep ascending ID order, chunk 3 is filtered out
+	assert.DeepEqual(t, []uint32{2, 4, 1, 5}, ids)
+
+	// lock is released before yielding sorted chunks
+	for c, err := range ds.List().Load(FieldMeta).SortBy(byRank).Iter() {
+		assert.NilError(t, err)
+		_, err = ds.Read(c.ID)
+		assert.NilError(t, err)
+	}
+}
+
+func TestListDesc(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		ds.Append(NewByteUnit([]byte(s), 0), nil, nil)
+	}
+	ds.Delete(3)
+
+	var pages [][]uint32
+	var after uint32
+	for {
+		var page []uint32
+		for c, err := range ds.List().Desc().After(after).Limit(2).Iter() {
+			assert.NilError(t, err)
+			page = append(page, c.ID)
+		}
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, page)
+		after = page[len(page)-1]
+	}
+	assert.DeepEqual(t, [][]uint32{{5, 4}, {2, 1}}, pages)
+
+	// ties in SortBy keep descending ID order
+	chunks, err := ds.List().Desc().SortBy(func(a, b *Chunk) int { return 0 }).All()
+	assert.NilError(t, err)
+	assert.Equal(t, uint32(5), chunks[0].ID)
+}
+
+func TestChangeFlagsWhere(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	names, _ := NewFlagNames("reviewed", "official")
+	reviewed, _ := names.Flag("reviewed")
+	official, _ := names.Flag("official")
+
+	for _, desc := range []uint8{1, 2, 1, 1} {
+		ds.Append(NewByteUnit([]byte("x"), desc), nil, nil)
+	}
+	ds.SetFlags(3, reviewed)
+
+	byDesc := func(c *Chunk) (bool, error) { return c.Data.Descriptor() == 1, nil }
+	n, err := ds.ChangeFlagsWhere(ds.List().Filter(byDesc), official, reviewed)
+	assert.NilError(t, err)
+	assert.Equal(t, 3, n)
+
+	// unchanged records are not counted
+	n, err = ds.ChangeFlagsWhere(ds.List().Filter(byDesc), official, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, 0, n)
+
+	chunks, err := ds.List().Filter(ByFlags(official)).All()
+	assert.NilError(t, err)
+	assert.Equal(t, 3, len(chunks))
+	c, _ := ds.Read(3)
+	assert.DeepEqual(t, []string{"official"}, names.Names(c.Flags))
+
+	_, err = ds.ChangeFlagsWhere(ds.List(), FlagDeleted, 0)
+	assert.NotNilError(t, err)
+}
+
+func TestUpdateMetaWhere(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	for _, m := range []string{"draft", "final", "draft"} {
+		ds.Append(NewByteUnit([]byte("x"), 0), NewByteUnit([]byte(m), 1), NewByteUnit([]byte{1}, 0))
+	}
+
+	patch := func(c *Chunk) (Unit, error) {
+		if string(c.Meta.Blob()) != "draft" {
+			return nil, nil
+		}
+		return NewByteUnit([]byte("published"), 1), nil
+	}
+
+	n, err := ds.UpdateMetaWhere(ds.List().Load(FieldMeta), patch, true)
+	assert.NilError(t, err)
+	assert.Equal(t, 2, n)
+	c, _ := ds.Read(1)
+	assert.DeepEqual(t, []byte("draft"), c.Meta.Blob())
+
+	n, err = ds.UpdateMetaWhere(ds.List().Load(FieldMeta), patch, false)
+	assert.NilError(t, err)
+	assert.Equal(t, 2, n)
+	c, _ = ds.Read(3)
+	assert.DeepEqual(t, []byte("published"), c.Meta.Blob())
+	assert.DeepEqual(t, []byte{1}, c.Vector.Blob())
+	c, _ = ds.Read(2)
+	assert.DeepEqual(t, []byte("final"), c.Meta.Blob())
+
+	// patch error leaves dataset unchanged
+	_, err = ds.UpdateMetaWhere(ds.List(), func(c *Chunk) (Unit, error) {
+		if c.ID == 3 {
+			return nil, errors.New("bad meta")
+		}
+		return NewByteUnit([]byte("x"), 1), nil
+	}, false)
+	assert.NotNilError(t, err)
+	c, _ = ds.Read(1)
+	assert.DeepEqual(t, []byte("published"), c.Meta.Blob())
+}
+
+func TestUpdateMetaWhereConcurrent(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+	id, err := ds.Append(NewByteUnit([]byte("x"), 0), NewByteUnit(nil, 1), nil)
+	assert.NilError(t, err)
+
+	// every call appends a byte to meta, a stale patch would lose one
+	patch := func(c *Chunk) (Unit, error) {
+		return NewByteUnit(append(c.Meta.Blob(), '+'), 1), nil
+	}
+	const n = 20
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = ds.UpdateMetaWhere(ds.List().Load(FieldMeta), patch, false)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NilError(t, err)
+	}
+
+	c, err := ds.Read(id)
+	assert.NilError(t, err)
+	assert.Equal(t, n, len(c.Meta.Blob()))
+
+	other := tempDataset(t)
+	defer other.Close()
+	_, err = ds.UpdateMetaWhere(other.List(), patch, false)
+	assert.NotNilError(t, err)
+}
+
+func TestDeleteWhere(t *testing.T) {
+	ds := tempDataset(t)
+	defer ds.Close()
+
+	for _, desc := range []uint8{1, 2, 1, 1} {
+		ds.Append(NewByteUnit([]byte("x"), desc), nil, nil)
+	}
+	byDesc := func(c *Chunk) (bool, error) { return c.Data.Descriptor() == 1, nil }
+
+	n, err := ds.DeleteWhere(ds.List().Filter(byDesc), DeleteOptions{MaxAffected: 2})
+	assert.Equal(t, true, errors.Is(err, ErrTooManyAffected))
+	assert.Equal(t, 3, n)
+
+	n, err = ds.DeleteWhere(ds.List().Filter(byDesc), DeleteOptions{DryRun: true})
+	assert.NilError(t, err)
+	assert.Equal(t, 3, n)
+	count, _ := ds.List().Count()
+	assert.Equal(t, 4, count)
+
+	n, err = ds.DeleteWhere(ds.List().Filter(byDesc), DeleteOptions{MaxAffected: 3})
+	assert.NilError(t, err)
+	assert.Equal(t, 3, n)
+	chunks, err := ds.List().All()
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(chunks))
+	assert.Equal(t, uint32(2), chunks[0].ID)
+
+	v, err := ds.Validate()
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(v))
+}
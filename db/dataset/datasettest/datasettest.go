@@ -0,0 +1,313 @@
+// Package datasettest provides a consistency harness for datasets.
+//
+// Check verifies that a dataset is internally consistent and that all read
+// APIs agree with each other. Model tracks the expected dataset content
+// alongside operations so that Compare can detect lost or stale records.
+// Run drives a dataset with a reproducible random sequence of operations
+// and checks it after every step. It is meant for tests of applications
+// embedding the dataset as well as the dataset package itself.
+package datasettest
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"github.com/webzak/mindstore/db/dataset"
+)
+
+// Record is the expected content of a chunk.
+type Record struct {
+	Data, Meta, Vector             []byte
+	DataDesc, MetaDesc, VectorDesc uint8
+	Flags                          uint8
+}
+
+// Model is the expected dataset content.
+type Model struct {
+	Records map[uint32]Record
+	// LastID is the highest ID ever allocated, deleted records included
+	LastID uint32
+}
+
+// NewModel creates an empty model.
+func NewModel() *Model {
+	return &Model{Records: make(map[uint32]Record)}
+}
+
+// Check verifies dataset invariants reported by Validate and cross-checks
+// List, Count, Read, ReadMany, ReadNoCopy and Stats against each other.
+func Check(ds *dataset.Dataset) error {
+	violations, err := ds.Validate()
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%d violations, first: %s: %s", len(violations), violations[0].Kind, violations[0].Message)
+	}
+
+	var listed []*dataset.Chunk
+	for c, err := range ds.List().Load(dataset.FieldData, dataset.FieldMeta, dataset.FieldVector).Iter() {
+		if err != nil {
+			return fmt.Errorf("list failed: %w", err)
+		}
+		listed = append(listed, c)
+	}
+	ids := make([]uint32, len(listed))
+	for i, c := range listed {
+		ids[i] = c.ID
+	}
+	if !slices.IsSorted(ids) {
+		return fmt.Errorf("list is not in ascending ID order")
+	}
+
+	n, err := ds.List().Count()
+	if err != nil {
+		return fmt.Errorf("count failed: %w", err)
+	}
+	if n != len(listed) {
+		return fmt.Errorf("count %d does not match listed %d", n, len(listed))
+	}
+	stats, err := ds.Stats()
+	if err != nil {
+		return fmt.Errorf("stats failed: %w", err)
+	}
+	if stats.Records != len(listed) {
+		return fmt.Errorf("stats records %d does not match listed %d", stats.Records, len(listed))
+	}
+
+	many, err := ds.ReadMany(ids)
+	if err != nil {
+		return fmt.Errorf("read many failed: %w", err)
+	}
+	for i, c := range listed {
+		r, err := ds.Read(c.ID)
+		if err != nil {
+			return fmt.Errorf("read %d failed: %w", c.ID, err)
+		}
+		if err := equalChunks(c, r); err != nil {
+			return fmt.Errorf("list and read differ for %d: %w", c.ID, err)
+		}
+		if err := equalChunks(c, many[i]); err != nil {
+			return fmt.Errorf("list and read many differ for %d: %w", c.ID, err)
+		}
+		err = ds.ReadNoCopy(c.ID, func(nc *dataset.Chunk) error {
+			return equalChunks(c, nc)
+		})
+		if err != nil {
+			return fmt.Errorf("list and read no copy differ for %d: %w", c.ID, err)
+		}
+	}
+	return nil
+}
+
+// Compare verifies that dataset content matches the model.
+func Compare(ds *dataset.Dataset, m *Model) error {
+	seen := 0
+	for c, err := range ds.List().Load(dataset.FieldData, dataset.FieldMeta, dataset.FieldVector).Iter() {
+		if err != nil {
+			return fmt.Errorf("list failed: %w", err)
+		}
+		r, ok := m.Records[c.ID]
+		if !ok {
+			return fmt.Errorf("unexpected chunk %d", c.ID)
+		}
+		if err := equalRecord(r, c); err != nil {
+			return fmt.Errorf("chunk %d: %w", c.ID, err)
+		}
+		seen++
+	}
+	if seen != len(m.Records) {
+		return fmt.Errorf("expected %d chunks, listed %d", len(m.Records), seen)
+	}
+	return nil
+}
+
+// Run applies n random operations chosen with seed to ds, which must be empty,
+// and verifies it with Check and Compare after each operation.
+// The returned model describes the final expected content.
+func Run(ds *dataset.Dataset, seed int64, n int) (*Model, error) {
+	rnd := rand.New(rand.NewSource(seed))
+	m := NewModel()
+	for step := range n {
+		op, err := applyRandomOp(ds, m, rnd)
+		if err != nil {
+			return m, fmt.Errorf("step %d %s: %w", step, op, err)
+		}
+		if err := Check(ds); err != nil {
+			return m, fmt.Errorf("step %d %s: %w", step, op, err)
+		}
+		if err := Compare(ds, m); err != nil {
+			return m, fmt.Errorf("step %d %s: %w", step, op, err)
+		}
+	}
+	return m, nil
+}
+
+// applyRandomOp applies a single random operation and updates the model
+func applyRandomOp(ds *dataset.Dataset, m *Model, rnd *rand.Rand) (string, error) {
+	ids := make([]uint32, 0, len(m.Records))
+	for id := range m.Records {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	pick := func() uint32 { return ids[rnd.Intn(len(ids))] }
+
+	switch p := rnd.Intn(100); {
+	case p < 40 || len(ids) == 0:
+		r := randomRecord(rnd)
+		id, err := ds.Append(unit(r.Data, r.DataDesc), unit(r.Meta, r.MetaDesc), unit(r.Vector, r.VectorDesc))
+		if err != nil {
+			return "append", err
+		}
+		if id != m.LastID+1 {
+			return "append", fmt.Errorf("expected id %d, got %d", m.LastID+1, id)
+		}
+		m.LastID = id
+		m.Records[id] = r
+		return "append", nil
+
+	case p < 45:
+		id := m.LastID + 1 + uint32(rnd.Intn(3))
+		r := randomRecord(rnd)
+		if err := ds.AppendAt(id, unit(r.Data, r.DataDesc), unit(r.Meta, r.MetaDesc), unit(r.Vector, r.VectorDesc)); err != nil {
+			return "append at", err
+		}
+		m.LastID = id
+		m.Records[id] = r
+		return "append at", nil
+
+	case p < 65:
+		id := pick()
+		r := m.Records[id]
+		nr := randomRecord(rnd)
+		var data, meta, vector dataset.Unit
+		// nil units keep current values, non-nil empty units clear them
+		if rnd.Intn(2) == 0 {
+			data = dataset.NewByteUnit(nr.Data, nr.DataDesc)
+			r.Data, r.DataDesc = nr.Data, nr.DataDesc
+		}
+		if rnd.Intn(2) == 0 {
+			meta = dataset.NewByteUnit(nr.Meta, nr.MetaDesc)
+			r.Meta, r.MetaDesc = nr.Meta, nr.MetaDesc
+		}
+		if rnd.Intn(2) == 0 {
+			vector = dataset.NewByteUnit(nr.Vector, nr.VectorDesc)
+			r.Vector, r.VectorDesc = nr.Vector, nr.VectorDesc
+		}
+		if err := ds.Update(id, data, meta, vector); err != nil {
+			return "update", err
+		}
+		m.Records[id] = r
+		return "update", nil
+
+	case p < 80:
+		id := pick()
+		if !ds.Delete(id) {
+			return "delete", fmt.Errorf("chunk %d was not deleted", id)
+		}
+		delete(m.Records, id)
+		return "delete", nil
+
+	case p < 90:
+		id := pick()
+		flag := dataset.IndexFlag(1 << (1 + rnd.Intn(7)))
+		r := m.Records[id]
+		if rnd.Intn(2) == 0 {
+			if err := ds.SetFlags(id, flag); err != nil {
+				return "set flags", err
+			}
+			r.Flags |= uint8(flag)
+		} else {
+			if err := ds.ClearFlags(id, flag); err != nil {
+				return "clear flags", err
+			}
+			r.Flags &^= uint8(flag)
+		}
+		m.Records[id] = r
+		return "flags", nil
+
+	case p < 95:
+		return "optimize", ds.Optimize()
+
+	default:
+		return "update config", ds.UpdateConfig(randomBytes(rnd, 8), true)
+	}
+}
+
+func randomRecord(rnd *rand.Rand) Record {
+	r := Record{
+		Data:     randomBytes(rnd, 64),
+		DataDesc: uint8(rnd.Intn(4)),
+	}
+	if rnd.Intn(2) == 0 {
+		r.Meta = randomBytes(rnd, 32)
+		r.MetaDesc = uint8(rnd.Intn(4))
+	}
+	if rnd.Intn(2) == 0 {
+		r.Vector = randomBytes(rnd, 32)
+		r.VectorDesc = uint8(rnd.Intn(4))
+	}
+	return r
+}
+
+func randomBytes(rnd *rand.Rand, maxLen int) []byte {
+	b := make([]byte, rnd.Intn(maxLen+1))
+	rnd.Read(b)
+	return b
+}
+
+// unit returns nil for a nil blob, Append then leaves the field empty
+func unit(blob []byte, desc uint8) dataset.Unit {
+	if blob == nil {
+		return nil
+	}
+	return dataset.NewByteUnit(blob, desc)
+}
+
+func equalRecord(r Record, c *dataset.Chunk) error {
+	if c.Flags&^uint8(dataset.FlagDeleted) != r.Flags {
+		return fmt.Errorf("flags: expected %08b, got %08b", r.Flags, c.Flags)
+	}
+	if err := equalUnit("data", r.Data, r.DataDesc, c.Data); err != nil {
+		return err
+	}
+	if err := equalUnit("meta", r.Meta, r.MetaDesc, c.Meta); err != nil {
+		return err
+	}
+	return equalUnit("vector", r.Vector, r.VectorDesc, c.Vector)
+}
+
+func equalUnit(name string, blob []byte, desc uint8, u dataset.Unit) error {
+	var got []byte
+	var gotDesc uint8
+	if u != nil {
+		got, gotDesc = u.Blob(), u.Descriptor()
+	}
+	if !bytes.Equal(blob, got) {
+		return fmt.Errorf("%s: expected %x, got %x", name, blob, got)
+	}
+	// descriptor of an empty field is not meaningful when it was never written
+	if len(blob) > 0 && desc != gotDesc {
+		return fmt.Errorf("%s descriptor: expected %d, got %d", name, desc, gotDesc)
+	}
+	return nil
+}
+
+func equalChunks(a, b *dataset.Chunk) error {
+	if a.ID != b.ID || a.Flags != b.Flags || a.Date != b.Date {
+		return fmt.Errorf("index fields differ")
+	}
+	r := Record{Flags: a.Flags &^ uint8(dataset.FlagDeleted)}
+	if a.Data != nil {
+		r.Data, r.DataDesc = a.Data.Blob(), a.Data.Descriptor()
+	}
+	if a.Meta != nil {
+		r.Meta, r.MetaDesc = a.Meta.Blob(), a.Meta.Descriptor()
+	}
+	if a.Vector != nil {
+		r.Vector, r.VectorDesc = a.Vector.Blob(), a.Vector.Descriptor()
+	}
+	return equalRecord(r, b)
+}
@@ -0,0 +1,61 @@
+package datasettest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/webzak/mindstore/db/dataset"
+)
+
+func TestRun(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		path := filepath.Join(t.TempDir(), "test.ds")
+		ds, err := dataset.NewDataset(path, 0, nil, 2)
+		if err != nil {
+			t.Fatalf("NewDataset failed: %v", err)
+		}
+		ds.SetCache(1 << 10)
+
+		m, err := Run(ds, seed, 200)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if err := ds.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		// content must survive reopen
+		ds, err = dataset.OpenDataset(path)
+		if err != nil {
+			t.Fatalf("OpenDataset failed: %v", err)
+		}
+		if err := Check(ds); err != nil {
+			t.Errorf("seed %d after reopen: %v", seed, err)
+		}
+		if err := Compare(ds, m); err != nil {
+			t.Errorf("seed %d after reopen: %v", seed, err)
+		}
+		ds.Close()
+	}
+}
+
+func TestCompareDetectsMismatch(t *testing.T) {
+	ds, err := dataset.NewDataset(filepath.Join(t.TempDir(), "test.ds"), 0, nil, 2)
+	if err != nil {
+		t.Fatalf("NewDataset failed: %v", err)
+	}
+	defer ds.Close()
+
+	id, _ := ds.Append(dataset.NewByteUnit([]byte("one"), 1), nil, nil)
+	m := NewModel()
+	m.Records[id] = Record{Data: []byte("two"), DataDesc: 1}
+	if err := Compare(ds, m); err == nil {
+		t.Error("expected data mismatch")
+	}
+
+	m.Records[id] = Record{Data: []byte("one"), DataDesc: 1}
+	m.Records[id+1] = Record{Data: []byte("lost")}
+	if err := Compare(ds, m); err == nil {
+		t.Error("expected missing chunk")
+	}
+}
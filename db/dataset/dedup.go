@@ -0,0 +1,122 @@
+package dataset
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"slices"
+)
+
+type contentHash [sha256.Size]byte
+
+// AppendUnique appends chunk unless a live chunk with the same data blob exists.
+// It returns the id of the new or existing chunk and whether it already existed.
+// Data is hashed after BeforeAppend hooks run, so the lookup sees the data
+// that would be written; hooks run even when an existing chunk is returned.
+// The content hash map is built on first call and then maintained by Append,
+// Update and Delete calls. Close saves it to the <path>.hashes file, so after
+// reopening data is read only for chunks changed since the hashes were saved.
+func (d *Dataset) AppendUnique(data, meta, vector Unit) (uint32, bool, error) {
+	hooks := d.hookList()
+	c := &Chunk{Data: data, Meta: meta, Vector: vector}
+	for _, h := range hooks {
+		if err := h.BeforeAppend(c); err != nil {
+			return 0, false, fmt.Errorf("append rejected by hook: %w", err)
+		}
+	}
+	if c.Data == nil || len(c.Data.Blob()) == 0 {
+		return 0, false, fmt.Errorf("data is required for unique append")
+	}
+
+	// Lookup and append share the critical section
+	d.Lock()
+	if err := d.buildHashes(); err != nil {
+		d.Unlock()
+		return 0, false, err
+	}
+	if ids, ok := d.hashes[sha256.Sum256(c.Data.Blob())]; ok {
+		d.Unlock()
+		return ids[0], true, nil
+	}
+	id, err := d.append(0, c.Data, c.Meta, c.Vector)
+	d.Unlock()
+	if err != nil {
+		return 0, false, err
+	}
+
+	c.ID = id
+	for _, h := range hooks {
+		h.AfterAppend(c)
+	}
+	return id, false, nil
+}
+
+// buildHashes hashes data of all live chunks if not done yet.
+// Hashes saved by Close are reused for chunks that have not changed since.
+// Caller must hold the lock.
+func (d *Dataset) buildHashes() error {
+	if d.hashes != nil {
+		return nil
+	}
+	ids := make([]uint32, 0, len(d.index))
+	for id := range d.index {
+		ids = append(ids, id)
+	}
+	// the lowest id represents its content
+	slices.Sort(ids)
+
+	saved := loadHashFile(d.path)
+	hashes := make(map[contentHash][]uint32, len(d.index))
+	hashByID := make(map[uint32]contentHash, len(d.index))
+	for _, id := range ids {
+		idx := d.index[id]
+		if r, ok := saved[id]; ok && r.matches(&idx) {
+			hashes[r.Hash] = append(hashes[r.Hash], id)
+			hashByID[id] = r.Hash
+			continue
+		}
+		c := &Chunk{}
+		if err := d.readChunkFields(c, &idx, []Field{FieldData}); err != nil {
+			return fmt.Errorf("failed to read chunk %d: %w", id, err)
+		}
+		if c.Data == nil || len(c.Data.Blob()) == 0 {
+			continue
+		}
+		h := contentHash(sha256.Sum256(c.Data.Blob()))
+		hashes[h] = append(hashes[h], id)
+		hashByID[id] = h
+	}
+	d.hashes = hashes
+	d.hashByID = hashByID
+	return nil
+}
+
+// trackHash records data hash for id if the hash map is built.
+// Caller must hold the lock.
+func (d *Dataset) trackHash(id uint32, data []byte) {
+	if d.hashes == nil || len(data) == 0 {
+		return
+	}
+	h := contentHash(sha256.Sum256(data))
+	d.hashes[h] = append(d.hashes[h], id)
+	d.hashByID[id] = h
+}
+
+// untrackHash removes data hash of id if the hash map is built.
+// Caller must hold the lock.
+func (d *Dataset) untrackHash(id uint32) {
+	if d.hashes == nil {
+		return
+	}
+	h, ok := d.hashByID[id]
+	if !ok {
+		return
+	}
+	delete(d.hashByID, id)
+	// the slice only holds chunks sharing this content
+	ids := slices.DeleteFunc(d.hashes[h], func(v uint32) bool { return v == id })
+	if len(ids) == 0 {
+		delete(d.hashes, h)
+		return
+	}
+	d.hashes[h] = ids
+}
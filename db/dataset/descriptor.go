@@ -0,0 +1,75 @@
+package dataset
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DescriptorInfo describes a user-defined unit descriptor.
+type DescriptorInfo struct {
+	Descriptor uint8
+	// MIME is a media type such as "text/plain" or a type wildcard such as "image/*"
+	MIME string
+	// Name is a human-readable name
+	Name string
+}
+
+// Descriptors is a registry mapping unit descriptors to MIME types and names.
+type Descriptors struct {
+	byDesc map[uint8]DescriptorInfo
+	byMIME map[string]uint8
+}
+
+// NewDescriptors creates an empty descriptor registry.
+func NewDescriptors() *Descriptors {
+	return &Descriptors{
+		byDesc: make(map[uint8]DescriptorInfo),
+		byMIME: make(map[string]uint8),
+	}
+}
+
+// Register adds descriptor info. Descriptor and MIME type must be unique.
+func (r *Descriptors) Register(info DescriptorInfo) error {
+	if info.MIME == "" {
+		return fmt.Errorf("MIME type cannot be empty")
+	}
+	mime := strings.ToLower(info.MIME)
+	if _, ok := r.byDesc[info.Descriptor]; ok {
+		return fmt.Errorf("descriptor %d is already registered", info.Descriptor)
+	}
+	if _, ok := r.byMIME[mime]; ok {
+		return fmt.Errorf("MIME type %s is already registered", info.MIME)
+	}
+	info.MIME = mime
+	r.byDesc[info.Descriptor] = info
+	r.byMIME[mime] = info.Descriptor
+	return nil
+}
+
+// Lookup returns info for descriptor.
+func (r *Descriptors) Lookup(desc uint8) (DescriptorInfo, bool) {
+	info, ok := r.byDesc[desc]
+	return info, ok
+}
+
+// ByMIME returns info for media type. Parameters such as charset are ignored.
+// If the exact type is not registered, a "type/*" wildcard is tried.
+func (r *Descriptors) ByMIME(mime string) (DescriptorInfo, bool) {
+	mime, _, _ = strings.Cut(mime, ";")
+	mime = strings.ToLower(strings.TrimSpace(mime))
+	if desc, ok := r.byMIME[mime]; ok {
+		return r.byDesc[desc], true
+	}
+	if main, _, ok := strings.Cut(mime, "/"); ok {
+		if desc, ok := r.byMIME[main+"/*"]; ok {
+			return r.byDesc[desc], true
+		}
+	}
+	return DescriptorInfo{}, false
+}
+
+// Sniff detects media type of data and returns the matching registered descriptor.
+func (r *Descriptors) Sniff(data []byte) (DescriptorInfo, bool) {
+	return r.ByMIME(http.DetectContentType(data))
+}
@@ -0,0 +1,147 @@
+package dataset
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxUserFlags is the amount of flag bits available to callers (bit 0 is reserved for deletion).
+const maxUserFlags = 7
+
+// FlagNames maps user-defined flag names to index flag bits.
+// Bits are assigned in order starting from bit 1.
+type FlagNames struct {
+	names []string
+}
+
+// NewFlagNames creates a registry for the given flag names.
+func NewFlagNames(names ...string) (*FlagNames, error) {
+	if len(names) > maxUserFlags {
+		return nil, fmt.Errorf("too many flag names: %d, max is %d", len(names), maxUserFlags)
+	}
+	seen := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		if n == "" {
+			return nil, fmt.Errorf("flag name cannot be empty")
+		}
+		if _, ok := seen[n]; ok {
+			return nil, fmt.Errorf("duplicate flag name: %s", n)
+		}
+		seen[n] = struct{}{}
+	}
+	return &FlagNames{names: names}, nil
+}
+
+// Flag returns the index flag for the given name.
+func (fn *FlagNames) Flag(name string) (IndexFlag, error) {
+	for i, n := range fn.names {
+		if n == name {
+			return IndexFlag(1 << (i + 1)), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown flag name: %s", name)
+}
+
+// Names returns the names of flags set in flags.
+func (fn *FlagNames) Names(flags uint8) []string {
+	var res []string
+	for i, n := range fn.names {
+		if flags&(1<<(i+1)) != 0 {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// ByFlags returns a filter that matches chunks having all of the given flags set.
+func ByFlags(flags IndexFlag) ChunkFilter {
+	return func(c *Chunk) (bool, error) {
+		return c.Flags&uint8(flags) == uint8(flags), nil
+	}
+}
+
+// SetFlags sets the given flags on the chunk with id.
+func (d *Dataset) SetFlags(id uint32, flags IndexFlag) error {
+	return d.changeFlags(id, flags, true)
+}
+
+// ClearFlags clears the given flags on the chunk with id.
+func (d *Dataset) ClearFlags(id uint32, flags IndexFlag) error {
+	return d.changeFlags(id, flags, false)
+}
+
+func (d *Dataset) changeFlags(id uint32, flags IndexFlag, set bool) error {
+	if flags&FlagDeleted != 0 {
+		return fmt.Errorf("deleted flag cannot be changed directly, use Delete")
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	idx, ok := d.index[id]
+	if !ok {
+		return fmt.Errorf("chunk with id %d not found", id)
+	}
+
+	if set {
+		idx.Flags |= uint8(flags)
+	} else {
+		idx.Flags &^= uint8(flags)
+	}
+	idx.Date = uint64(time.Now().Unix())
+
+	if err := idx.writeAt(d.f, d.header.size()); err != nil {
+		return fmt.Errorf("failed to write index record: %w", err)
+	}
+	if err := d.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+
+	d.index[id] = idx
+	return nil
+}
+
+// ChangeFlagsWhere sets and clears flags on all chunks yielded by list and
+// returns the amount of changed chunks. Index records are written in one pass
+// with a single sync. The dataset lock is held from list evaluation until the
+// sync, list filters and comparators must not call methods of the dataset.
+func (d *Dataset) ChangeFlagsWhere(list *ListBuilder, set, clear IndexFlag) (int, error) {
+	if (set|clear)&FlagDeleted != 0 {
+		return 0, fmt.Errorf("deleted flag cannot be changed directly, use Delete")
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	list, err := list.underLock(d)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := list.ids()
+	if err != nil {
+		return 0, err
+	}
+
+	now := uint64(time.Now().Unix())
+	changed := 0
+	for _, id := range ids {
+		idx := d.index[id]
+		flags := (idx.Flags | uint8(set)) &^ uint8(clear)
+		if flags == idx.Flags {
+			continue
+		}
+		idx.Flags = flags
+		idx.Date = now
+		if err := idx.writeAt(d.f, d.header.size()); err != nil {
+			return changed, fmt.Errorf("failed to write index record: %w", err)
+		}
+		d.index[id] = idx
+		changed++
+	}
+	if changed > 0 {
+		if err := d.f.Sync(); err != nil {
+			return changed, fmt.Errorf("failed to sync file: %w", err)
+		}
+	}
+	return changed, nil
+}
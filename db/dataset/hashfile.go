@@ -0,0 +1,106 @@
+package dataset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	hashFileMagic = 0x19720612
+	// id u32, position u64, size u64, date u64, hash
+	sizeHashRec = size32 + 3*size64 + len(contentHash{})
+)
+
+// hashRec is a persisted content hash of the chunk described by index fields.
+type hashRec struct {
+	Position uint64
+	Size     uint64
+	Date     uint64
+	Hash     contentHash
+}
+
+// matches reports whether rec was computed for the chunk idx points to.
+// Data space is append-only, so a chunk with the same position, size and
+// date holds the same data.
+func (r *hashRec) matches(idx *index) bool {
+	return r.Position == idx.Position && r.Size == idx.Size && r.Date == idx.Date
+}
+
+// hashFilePath returns path of the content hash sidecar of dataset at path.
+func hashFilePath(path string) string {
+	return path + ".hashes"
+}
+
+// loadHashFile reads the content hash sidecar keyed by chunk id.
+// A missing or malformed file yields no records, so hashes are recomputed.
+func loadHashFile(path string) map[uint32]hashRec {
+	buf, err := os.ReadFile(hashFilePath(path))
+	if err != nil || len(buf) < 2*size32 || binary.LittleEndian.Uint32(buf) != hashFileMagic {
+		return nil
+	}
+	count := int(binary.LittleEndian.Uint32(buf[size32:]))
+	buf = buf[2*size32:]
+	if len(buf) != count*sizeHashRec {
+		return nil
+	}
+	recs := make(map[uint32]hashRec, count)
+	for i := range count {
+		b := buf[i*sizeHashRec:]
+		var r hashRec
+		id := binary.LittleEndian.Uint32(b)
+		r.Position = binary.LittleEndian.Uint64(b[size32:])
+		r.Size = binary.LittleEndian.Uint64(b[size32+size64:])
+		r.Date = binary.LittleEndian.Uint64(b[size32+2*size64:])
+		copy(r.Hash[:], b[size32+3*size64:])
+		recs[id] = r
+	}
+	return recs
+}
+
+// saveHashFile writes content hashes of live chunks to the sidecar.
+// Caller must hold the lock.
+func (d *Dataset) saveHashFile() error {
+	buf := make([]byte, 2*size32, 2*size32+len(d.hashByID)*sizeHashRec)
+	binary.LittleEndian.PutUint32(buf, hashFileMagic)
+	binary.LittleEndian.PutUint32(buf[size32:], uint32(len(d.hashByID)))
+	for id, h := range d.hashByID {
+		idx := d.index[id]
+		buf = binary.LittleEndian.AppendUint32(buf, id)
+		buf = binary.LittleEndian.AppendUint64(buf, idx.Position)
+		buf = binary.LittleEndian.AppendUint64(buf, idx.Size)
+		buf = binary.LittleEndian.AppendUint64(buf, idx.Date)
+		buf = append(buf, h[:]...)
+	}
+
+	path := hashFilePath(d.path)
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create hash file: %w", err)
+	}
+	_, err = f.Write(buf)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write hash file: %w", err)
+	}
+	return nil
+}
+
+// removeHashFile deletes the content hash sidecar of dataset at path.
+func removeHashFile(path string) error {
+	if err := os.Remove(hashFilePath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove hash file: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package dataset
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -11,6 +12,9 @@ const (
 	magic = 0x19720611
 )
 
+// ErrCorrupted is returned when file structure is inconsistent
+var ErrCorrupted = errors.New("dataset file is corrupted")
+
 // header represents the dataset file starting part up to index space
 type header struct {
 	magic      uint32
@@ -31,7 +35,7 @@ func (h *header) size() int64 {
 
 // data space position in file
 func (h *header) dataSpacePos() int64 {
-	return h.size() + int64(h.indexCap*sizeIndexRec)
+	return h.size() + int64(h.indexCap)*sizeIndexRec
 }
 
 func (h *header) blob() []byte {
@@ -61,6 +65,10 @@ func (h *header) blob() []byte {
 }
 
 func readHeader(f *os.File) (*header, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
 	if _, err := f.Seek(0, io.SeekStart); err != nil {
 		return nil, fmt.Errorf("failed to seek: %w", err)
 	}
@@ -83,6 +91,11 @@ func readHeader(f *os.File) (*header, error) {
 	// Extract configSize
 	configSize := binary.LittleEndian.Uint32(initialBuf[sizeMagic+size32:])
 
+	// Config size is checked before allocation so that a bogus value fails fast
+	if int64(configSize) > fi.Size()-int64(sizeMagic+size32*4) {
+		return nil, fmt.Errorf("%w: config size %d exceeds file size %d", ErrCorrupted, configSize, fi.Size())
+	}
+
 	// Calculate and read remaining header data
 	remainingSize := int(configSize) + size32*2
 	remainingBuf := make([]byte, remainingSize)
@@ -103,6 +116,13 @@ func readHeader(f *os.File) (*header, error) {
 	// Extract indexLen
 	indexLen := binary.LittleEndian.Uint32(remainingBuf[offset:])
 
+	if indexCap > maxIndexCap {
+		return nil, fmt.Errorf("%w: index capacity %d exceeds %d", ErrCorrupted, indexCap, maxIndexCap)
+	}
+	if indexLen > indexCap {
+		return nil, fmt.Errorf("%w: index length %d exceeds capacity %d", ErrCorrupted, indexLen, indexCap)
+	}
+
 	// Construct header struct
 	h := &header{
 		magic:      readMagic,
@@ -112,6 +132,9 @@ func readHeader(f *os.File) (*header, error) {
 		indexCap:   indexCap,
 		indexLen:   indexLen,
 	}
+	if fi.Size() < h.dataSpacePos() {
+		return nil, fmt.Errorf("%w: file size %d is less than index space end %d", ErrCorrupted, fi.Size(), h.dataSpacePos())
+	}
 
 	return h, nil
 }
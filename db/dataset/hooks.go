@@ -0,0 +1,44 @@
+package dataset
+
+// Hook is called around dataset operations. It can be used for auditing,
+// content validation or enriching chunks before they are written.
+// Hooks are called without the dataset lock held, so they may use the dataset.
+//
+// Hooks cover Append, AppendAt, AppendUnique, Read, ReadMany, ReadNoCopy and
+// Vectors only. List runs its pipeline with the lock held and does not call
+// BeforeRead, so List().Load(...) reads payloads of any chunk. Update and
+// UpdateMetaWhere do not call BeforeAppend, so replaced blobs are not
+// validated or enriched. A hook must not be relied on as an access control
+// or validation boundary when these methods are reachable.
+type Hook interface {
+	// BeforeAppend is called before chunk is appended. The chunk has zero ID,
+	// its Data, Meta and Vector may be replaced. Returning error rejects the append.
+	// It is not called by Update.
+	BeforeAppend(c *Chunk) error
+	// AfterAppend is called after chunk is written with ID set.
+	AfterAppend(c *Chunk)
+	// BeforeRead is called before chunk with id is read. Returning error rejects the read.
+	// It is not called by List.
+	BeforeRead(id uint32) error
+}
+
+// NopHook implements Hook with no-op methods. Embed it to implement only needed methods.
+type NopHook struct{}
+
+func (NopHook) BeforeAppend(c *Chunk) error { return nil }
+func (NopHook) AfterAppend(c *Chunk)        {}
+func (NopHook) BeforeRead(id uint32) error  { return nil }
+
+// AddHook registers a hook. Hooks are called in registration order.
+func (d *Dataset) AddHook(h Hook) {
+	d.Lock()
+	defer d.Unlock()
+	d.hooks = append(d.hooks, h)
+}
+
+// hookList returns registered hooks.
+func (d *Dataset) hookList() []Hook {
+	d.Lock()
+	defer d.Unlock()
+	return d.hooks
+}
@@ -3,7 +3,6 @@ package dataset
 import (
 	"encoding/binary"
 	"fmt"
-	"io"
 	"os"
 )
 
@@ -27,6 +26,8 @@ type index struct {
 	Size uint64
 	// Date is unix timestamp in seconds
 	Date uint64
+	// slot is the record number in index space, not stored in file
+	slot uint32
 }
 
 func (i *index) size() int64 {
@@ -55,42 +56,58 @@ func (i *index) blob() []byte {
 }
 
 func (i *index) writeAt(f *os.File, headerSize int64) error {
-	pos := headerSize + int64(i.ID-1)*sizeIndexRec
+	pos := headerSize + int64(i.slot)*sizeIndexRec
 	_, err := f.WriteAt(i.blob(), pos)
 	return err
 }
 
+// parseIndex parses index record from buf
+func parseIndex(buf []byte) index {
+	return index{
+		ID:         binary.LittleEndian.Uint32(buf[0:]),
+		Flags:      buf[4],
+		DataDesc:   buf[5],
+		MetaDesc:   buf[6],
+		VectorDesc: buf[7],
+		Position:   binary.LittleEndian.Uint64(buf[8:]),
+		Size:       binary.LittleEndian.Uint64(buf[16:]),
+		Date:       binary.LittleEndian.Uint64(buf[24:]),
+	}
+}
+
 func readIndex(f *os.File, h *header) (map[uint32]index, uint32, error) {
 	if h.indexLen == 0 {
 		return make(map[uint32]index), 0, nil
 	}
 
 	buf := make([]byte, h.indexLen*sizeIndexRec)
-	if _, err := io.ReadFull(f, buf); err != nil {
+	if _, err := f.ReadAt(buf, h.size()); err != nil {
 		return nil, 0, fmt.Errorf("failed to read index: %w", err)
 	}
 
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+	dataSpaceSize := uint64(max(fi.Size()-h.dataSpacePos(), 0))
+
 	idx := make(map[uint32]index, h.indexLen)
 	var lastID uint32
 
 	for i := uint32(0); i < h.indexLen; i++ {
-		offset := int(i * sizeIndexRec)
-		rec := index{
-			ID:         binary.LittleEndian.Uint32(buf[offset:]),
-			Flags:      buf[offset+4],
-			DataDesc:   buf[offset+5],
-			MetaDesc:   buf[offset+6],
-			VectorDesc: buf[offset+7],
-			Position:   binary.LittleEndian.Uint64(buf[offset+8:]),
-			Size:       binary.LittleEndian.Uint64(buf[offset+16:]),
-			Date:       binary.LittleEndian.Uint64(buf[offset+24:]),
-		}
+		rec := parseIndex(buf[i*sizeIndexRec:])
+		rec.slot = i
 		if rec.ID > lastID {
 			lastID = rec.ID
 		}
 		if rec.isDeleted() {
 			continue
 		}
+		// Reads allocate Size bytes, a record must not point outside data space
+		if rec.Size < sizeChunkHeader || rec.Size > dataSpaceSize || rec.Position > dataSpaceSize-rec.Size {
+			return nil, 0, fmt.Errorf("%w: chunk %d at slot %d has position %d and size %d outside data space of %d bytes",
+				ErrCorrupted, rec.ID, i, rec.Position, rec.Size, dataSpaceSize)
+		}
 		idx[rec.ID] = rec
 	}
 
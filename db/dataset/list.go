@@ -1,6 +1,12 @@
 package dataset
 
-import "iter"
+import (
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+)
 
 // ChunkFilter is a predicate function for filtering chunks in a pipeline.
 // Returns (true, nil) to include, (false, nil) to skip, (_, error) to stop with error.
@@ -22,6 +28,13 @@ func ByIDs(ids ...uint32) ChunkFilter {
 type ListBuilder struct {
 	ds     *Dataset
 	stages []listStage
+	after  uint32
+	limit  int
+	ctx    context.Context
+	cmp    func(a, b *Chunk) int
+	desc   bool
+	// locked tells that the caller already holds the dataset lock
+	locked bool
 }
 
 type stageKind uint8
@@ -63,15 +76,146 @@ func (b *ListBuilder) Load(fields ...Field) *ListBuilder {
 	return b
 }
 
+// After makes the pipeline start with the first chunk having ID greater than id,
+// or less than id in descending order. Zero means start from the first chunk.
+// The ID of the last chunk received can be used as a continuation token.
+func (b *ListBuilder) After(id uint32) *ListBuilder {
+	b.after = id
+	return b
+}
+
+// Desc makes the pipeline yield chunks in descending ID order, newest first.
+func (b *ListBuilder) Desc() *ListBuilder {
+	b.desc = true
+	return b
+}
+
+// Context makes the iteration stop with ctx error when ctx is done.
+// The context is checked before each chunk.
+func (b *ListBuilder) Context(ctx context.Context) *ListBuilder {
+	b.ctx = ctx
+	return b
+}
+
+// Limit stops the iteration after n chunks are yielded. Zero means no limit.
+func (b *ListBuilder) Limit(n int) *ListBuilder {
+	b.limit = n
+	return b
+}
+
+// SortBy makes the pipeline yield chunks ordered by cmp, which typically
+// compares loaded meta fields. Ties keep ID order, see Desc.
+// Matching chunks are collected before sorting, Limit applies to the sorted result.
+func (b *ListBuilder) SortBy(cmp func(a, b *Chunk) int) *ListBuilder {
+	b.cmp = cmp
+	return b
+}
+
 // Iter returns an iterator that executes the pipeline.
-// The iterator holds the dataset lock for its entire duration.
+// Chunks are yielded in ascending ID order unless Desc or SortBy is set.
+// Without SortBy the iterator holds the dataset lock for its entire duration,
+// with SortBy the lock is released before the first chunk is yielded.
 // Errors from filters or I/O are yielded and stop iteration.
 func (b *ListBuilder) Iter() iter.Seq2[*Chunk, error] {
+	if b.cmp != nil {
+		return b.sorted()
+	}
+	return b.scan(b.limit)
+}
+
+// All executes the pipeline and returns matching chunks.
+func (b *ListBuilder) All() ([]*Chunk, error) {
+	var res []*Chunk
+	for c, err := range b.Iter() {
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// ids executes the pipeline and returns IDs of matching chunks
+func (b *ListBuilder) ids() ([]uint32, error) {
+	var ids []uint32
+	for c, err := range b.Iter() {
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+// underLock returns a copy of the pipeline to be run by a caller holding the lock of d
+func (b *ListBuilder) underLock(d *Dataset) (*ListBuilder, error) {
+	if b.ds != d {
+		return nil, fmt.Errorf("list belongs to another dataset")
+	}
+	l := *b
+	l.locked = true
+	return &l, nil
+}
+
+// sorted collects all matching chunks and yields them ordered by b.cmp
+func (b *ListBuilder) sorted() iter.Seq2[*Chunk, error] {
 	return func(yield func(*Chunk, error) bool) {
-		b.ds.Lock()
-		defer b.ds.Unlock()
+		var chunks []*Chunk
+		for c, err := range b.scan(0) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			chunks = append(chunks, c)
+		}
+		slices.SortStableFunc(chunks, b.cmp)
+		if b.limit > 0 && len(chunks) > b.limit {
+			chunks = chunks[:b.limit]
+		}
+		for _, c := range chunks {
+			if !yield(c, nil) {
+				return
+			}
+		}
+	}
+}
+
+// scan executes the pipeline in ID order holding the lock,
+// stopping after limit chunks, zero means no limit.
+func (b *ListBuilder) scan(limit int) iter.Seq2[*Chunk, error] {
+	return func(yield func(*Chunk, error) bool) {
+		if !b.locked {
+			b.ds.Lock()
+			defer b.ds.Unlock()
+		}
+
+		ids := slices.Sorted(maps.Keys(b.ds.index))
+		start, found := slices.BinarySearch(ids, b.after)
+		if b.desc {
+			if b.after > 0 {
+				ids = ids[:start]
+			}
+			slices.Reverse(ids)
+		} else {
+			if found {
+				start++
+			}
+			ids = ids[start:]
+		}
+
+		yielded := 0
+		for _, id := range ids {
+			if limit > 0 && yielded >= limit {
+				return
+			}
+			if b.ctx != nil {
+				if err := b.ctx.Err(); err != nil {
+					yield(nil, err)
+					return
+				}
+			}
+			idx := b.ds.index[id]
 
-		for _, idx := range b.ds.index {
 			// Skip deleted records
 			if idx.isDeleted() {
 				continue
@@ -118,9 +262,42 @@ func (b *ListBuilder) Iter() iter.Seq2[*Chunk, error] {
 				continue
 			}
 
+			yielded++
 			if !yield(chunk, nil) {
 				return
 			}
 		}
 	}
 }
+
+// Count executes the pipeline and returns the amount of matching chunks.
+func (b *ListBuilder) Count() (int, error) {
+	n := 0
+	for _, err := range b.Iter() {
+		if err != nil {
+			return 0, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// CountBy executes the pipeline and counts matching chunks per key.
+// The key function typically decodes a loaded meta field, chunks for which
+// it returns an empty key are not counted.
+func (b *ListBuilder) CountBy(key func(c *Chunk) (string, error)) (map[string]int, error) {
+	counts := make(map[string]int)
+	for c, err := range b.Iter() {
+		if err != nil {
+			return nil, err
+		}
+		k, err := key(c)
+		if err != nil {
+			return nil, err
+		}
+		if k != "" {
+			counts[k]++
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,11 @@
+//go:build (!unix && !windows) || aix || (solaris && !illumos)
+
+package dataset
+
+import "os"
+
+// lockFile is a no-op on platforms without flock or LockFileEx,
+// opening the same dataset twice is not detected there.
+func lockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+package dataset
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32    = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx = modkernel32.NewProc("LockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	errorLockViolation syscall.Errno = 33
+)
+
+// lockFile takes an exclusive lock on the first byte of f without waiting.
+// The lock is released when f is closed.
+func lockFile(f *os.File) error {
+	ol := &syscall.Overlapped{}
+	r, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(ol)))
+	if r == 0 {
+		if errors.Is(err, errorLockViolation) {
+			return ErrLocked
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package dataset
+
+import "unsafe"
+
+// mapEntryOverhead approximates per-entry bookkeeping of Go maps in bytes
+const mapEntryOverhead = 16
+
+// MemoryStats contains approximate amounts of memory held by the dataset in bytes.
+type MemoryStats struct {
+	// Index is held by the in-memory index
+	Index int64
+	// Cache is held by the chunk cache, see SetCache
+	Cache int64
+	// Hashes is held by content hash maps, see AppendUnique
+	Hashes int64
+	// Config is held by the header config copy
+	Config int64
+	// Total is the sum of all above
+	Total int64
+}
+
+// MemoryStats returns approximate memory usage of the dataset.
+func (d *Dataset) MemoryStats() MemoryStats {
+	d.Lock()
+	defer d.Unlock()
+
+	var m MemoryStats
+	m.Index = int64(len(d.index)) * int64(unsafe.Sizeof(uint32(0))+unsafe.Sizeof(index{})+mapEntryOverhead)
+	if d.cache != nil {
+		m.Cache = d.cache.bytes + int64(d.cache.ll.Len())*int64(unsafe.Sizeof(cacheEntry{})+unsafe.Sizeof(uint32(0))+mapEntryOverhead)
+	}
+	if d.hashes != nil {
+		perHash := int64(unsafe.Sizeof(contentHash{}) + unsafe.Sizeof([]uint32(nil)) + mapEntryOverhead)
+		// every id is stored in a hash slice and in the reverse map
+		perID := int64(2*unsafe.Sizeof(uint32(0)) + unsafe.Sizeof(contentHash{}) + mapEntryOverhead)
+		m.Hashes = int64(len(d.hashes))*perHash + int64(len(d.hashByID))*perID
+	}
+	m.Config = int64(len(d.header.config))
+	m.Total = m.Index + m.Cache + m.Hashes + m.Config
+	return m
+}
@@ -0,0 +1,66 @@
+package dataset
+
+import (
+	"fmt"
+	"sync"
+)
+
+// readBufPool holds buffers used by ReadNoCopy
+var readBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 4096)
+		return &b
+	},
+}
+
+// ReadNoCopy reads the chunk by ID and passes it to fn without copying blobs.
+// Blobs alias internal buffers (the cache or a pooled read buffer) and are only
+// valid until fn returns; fn must not modify or retain them.
+// The dataset lock is held while fn runs, so fn must not call dataset methods.
+func (d *Dataset) ReadNoCopy(id uint32, fn func(c *Chunk) error) error {
+	for _, h := range d.hookList() {
+		if err := h.BeforeRead(id); err != nil {
+			return fmt.Errorf("read rejected by hook: %w", err)
+		}
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	idx, ok := d.index[id]
+	if !ok {
+		return fmt.Errorf("chunk with id %d not found", id)
+	}
+
+	if d.cache != nil {
+		if buf, ok := d.cache.peek(id); ok {
+			cr, err := parseChunk(buf)
+			if err != nil {
+				return err
+			}
+			return fn(idx.chunk(cr, newFieldSet(nil)))
+		}
+	}
+
+	bp := readBufPool.Get().(*[]byte)
+	buf := *bp
+	if uint64(cap(buf)) < idx.Size {
+		buf = make([]byte, idx.Size)
+	}
+	buf = buf[:idx.Size]
+	defer func() {
+		if cap(buf) <= maxPooledBuf {
+			*bp = buf[:0]
+			readBufPool.Put(bp)
+		}
+	}()
+
+	if _, err := d.f.ReadAt(buf, d.header.dataSpacePos()+int64(idx.Position)); err != nil {
+		return fmt.Errorf("failed to read chunk: %w", err)
+	}
+	cr, err := parseChunk(buf)
+	if err != nil {
+		return err
+	}
+	return fn(idx.chunk(cr, newFieldSet(nil)))
+}
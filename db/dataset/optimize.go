@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"slices"
+	"time"
 )
 
 // Optimize removes deleted records and compacts the dataset file.
@@ -18,6 +19,8 @@ func (d *Dataset) Optimize() error {
 		return nil
 	}
 
+	start := time.Now()
+
 	// Collect and sort IDs
 	ids := make([]uint32, 0, len(d.index))
 	for id := range d.index {
@@ -99,6 +102,7 @@ func (d *Dataset) Optimize() error {
 			Position:   dataPos,
 			Size:       oldIdx.Size,
 			Date:       oldIdx.Date,
+			slot:       uint32(i),
 		}
 
 		// Write to index buffer at sequential slot position
@@ -126,19 +130,9 @@ func (d *Dataset) Optimize() error {
 	if err := tmpFile.Close(); err != nil {
 		return fmt.Errorf("failed to close temp file: %w", err)
 	}
-	if err := d.f.Close(); err != nil {
-		return fmt.Errorf("failed to close original file: %w", err)
-	}
-
-	// Atomically replace original with temp
-	if err := os.Rename(tmpPath, d.path); err != nil {
-		return fmt.Errorf("failed to replace file: %w", err)
-	}
-
-	// Reopen the file
-	newFile, err := os.OpenFile(d.path, os.O_RDWR, 0644)
+	newFile, err := d.replaceFile(tmpPath)
 	if err != nil {
-		return fmt.Errorf("failed to reopen file: %w", err)
+		return err
 	}
 
 	// Update Dataset state
@@ -148,5 +142,6 @@ func (d *Dataset) Optimize() error {
 	// d.lastID stays unchanged
 
 	success = true
+	d.logger.Debug("dataset optimized", "path", d.path, "records", newLen, "duration", time.Since(start))
 	return nil
 }
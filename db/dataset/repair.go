@@ -0,0 +1,171 @@
+package dataset
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// RepairReport describes changes made by Repair.
+type RepairReport struct {
+	// DroppedRecords is the amount of incomplete index records removed from the index tail
+	DroppedRecords int
+	// DeletedRecords is the amount of records pointing to missing or invalid chunks marked as deleted
+	DeletedRecords int
+	// TruncatedBytes is the amount of bytes removed from the end of data space
+	TruncatedBytes int64
+}
+
+// Changed reports whether the file was modified.
+func (r *RepairReport) Changed() bool {
+	return r.DroppedRecords > 0 || r.DeletedRecords > 0 || r.TruncatedBytes > 0
+}
+
+// Repair detects and repairs a torn tail left by an interrupted write.
+// Unlike the check done by OpenDataset it validates every live record.
+func Repair(path string) (*RepairReport, error) {
+	f, lock, err := openLocked(path)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Close()
+	defer f.Close()
+
+	h, err := readHeader(f)
+	if err != nil {
+		return nil, err
+	}
+	return repair(f, h, true)
+}
+
+// repair validates index records against chunks in data space.
+// Invalid records at the end of index are dropped by decreasing the index length,
+// invalid records in the middle (torn updates) are marked as deleted.
+// Data space is truncated after the end of the last live chunk.
+//
+// Without full only the tail is checked: chunks are always written at the end
+// of data space and every operation ends with a sync, so live records are
+// checked in descending chunk position until the first intact chunk, which
+// marks the last durable point.
+func repair(f *os.File, h *header, full bool) (*RepairReport, error) {
+	report := &RepairReport{}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	dataSpaceSize := fi.Size() - h.dataSpacePos()
+	if dataSpaceSize < 0 {
+		return nil, fmt.Errorf("%w: file is shorter than index space: %d bytes", ErrCorrupted, fi.Size())
+	}
+	if h.indexLen > h.indexCap {
+		return nil, fmt.Errorf("%w: index length %d exceeds capacity %d", ErrCorrupted, h.indexLen, h.indexCap)
+	}
+
+	buf := make([]byte, h.indexLen*sizeIndexRec)
+	if _, err := f.ReadAt(buf, h.size()); err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	recs := make([]index, h.indexLen)
+	valid := make([]bool, h.indexLen)
+	var live []int
+	for i := range recs {
+		recs[i] = parseIndex(buf[i*sizeIndexRec:])
+		switch {
+		case recs[i].ID == 0:
+		case recs[i].isDeleted():
+			valid[i] = true
+		default:
+			live = append(live, i)
+		}
+	}
+	if !full {
+		sort.Slice(live, func(a, b int) bool {
+			return recs[live[a]].Position > recs[live[b]].Position
+		})
+	}
+	for n, i := range live {
+		ok, err := chunkValid(f, h, &recs[i], uint64(dataSpaceSize))
+		if err != nil {
+			return nil, err
+		}
+		valid[i] = ok
+		if ok && !full {
+			// chunks before the last durable point are trusted
+			for _, j := range live[n+1:] {
+				valid[j] = true
+			}
+			break
+		}
+	}
+
+	lastValid := -1
+	var dataEnd uint64
+	for i, rec := range recs {
+		if !valid[i] {
+			continue
+		}
+		lastValid = i
+		if !rec.isDeleted() {
+			dataEnd = max(dataEnd, rec.Position+rec.Size)
+		}
+	}
+
+	// Mark invalid records before the last valid one as deleted
+	for i := 0; i < lastValid; i++ {
+		if valid[i] {
+			continue
+		}
+		rec := recs[i]
+		if rec.ID == 0 {
+			return nil, fmt.Errorf("%w: empty index record at slot %d", ErrCorrupted, i)
+		}
+		rec.setDeleted()
+		if _, err := f.WriteAt(rec.blob(), h.size()+int64(i)*sizeIndexRec); err != nil {
+			return nil, fmt.Errorf("failed to write index record: %w", err)
+		}
+		report.DeletedRecords++
+	}
+
+	// Drop invalid records at the index tail
+	if newLen := uint32(lastValid + 1); newLen < h.indexLen {
+		report.DroppedRecords = int(h.indexLen - newLen)
+		h.indexLen = newLen
+		if _, err := f.WriteAt(h.blob(), 0); err != nil {
+			return nil, fmt.Errorf("failed to update header: %w", err)
+		}
+	}
+
+	// Cut data written after the last live chunk
+	if int64(dataEnd) < dataSpaceSize {
+		report.TruncatedBytes = dataSpaceSize - int64(dataEnd)
+		if err := f.Truncate(h.dataSpacePos() + int64(dataEnd)); err != nil {
+			return nil, fmt.Errorf("failed to truncate file: %w", err)
+		}
+	}
+
+	if report.Changed() {
+		if err := f.Sync(); err != nil {
+			return nil, fmt.Errorf("failed to sync file: %w", err)
+		}
+	}
+	return report, nil
+}
+
+// chunkValid checks that chunk referenced by rec lies within data space
+// and its size fields match the record size.
+func chunkValid(f *os.File, h *header, rec *index, dataSpaceSize uint64) (bool, error) {
+	if rec.Size < sizeChunkHeader || rec.Position > dataSpaceSize || rec.Size > dataSpaceSize-rec.Position {
+		return false, nil
+	}
+	var sizeBuf [sizeChunkHeader]byte
+	if _, err := f.ReadAt(sizeBuf[:], h.dataSpacePos()+int64(rec.Position)); err != nil {
+		return false, fmt.Errorf("failed to read chunk sizes: %w", err)
+	}
+	dataSize := binary.LittleEndian.Uint64(sizeBuf[0:])
+	metaSize := binary.LittleEndian.Uint32(sizeBuf[8:])
+	vectorSize := binary.LittleEndian.Uint32(sizeBuf[12:])
+	return checkChunkSizes(rec.Size, dataSize, metaSize, vectorSize) == nil, nil
+}
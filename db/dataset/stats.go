@@ -0,0 +1,87 @@
+package dataset
+
+import "fmt"
+
+// optimizeThreshold is the dead space ratio above which Optimize is recommended
+const optimizeThreshold = 0.25
+
+// Stats contains dataset statistics for inspection.
+type Stats struct {
+	// Records is the amount of live (not deleted) records
+	Records int
+	// Deleted is the amount of index records marked as deleted
+	Deleted int
+	// IndexCap is the amount of actual and reserved index records
+	IndexCap int
+	// FileSize is the total file size in bytes
+	FileSize int64
+	// DataSpaceSize is the size of data space in bytes
+	DataSpaceSize int64
+	// MinChunkSize, MaxChunkSize and AvgChunkSize describe live chunk sizes in bytes
+	MinChunkSize uint64
+	MaxChunkSize uint64
+	AvgChunkSize float64
+	// DataDescriptors maps data descriptor to the amount of live records using it
+	DataDescriptors map[uint8]int
+	// DeletedRatio is Deleted divided by the total amount of index records
+	DeletedRatio float64
+	// LiveBytes is the size of chunks referenced by live records
+	LiveBytes int64
+	// DeadBytes is the size of data space not referenced by live records
+	// (deleted chunks and chunks superseded by Update)
+	DeadBytes int64
+	// Fragmentation is DeadBytes divided by DataSpaceSize
+	Fragmentation float64
+	// UnusedIndexBytes is the size of reserved but unused index space
+	UnusedIndexBytes int64
+	// OptimizeRecommended is true when Fragmentation exceeds the threshold
+	OptimizeRecommended bool
+}
+
+// Stats calculates dataset statistics from the in-memory index and file size.
+func (d *Dataset) Stats() (*Stats, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	fi, err := d.f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	s := &Stats{
+		Records:         len(d.index),
+		Deleted:         int(d.header.indexLen) - len(d.index),
+		IndexCap:        int(d.header.indexCap),
+		FileSize:        fi.Size(),
+		DataSpaceSize:   fi.Size() - d.header.dataSpacePos(),
+		DataDescriptors: make(map[uint8]int),
+	}
+
+	var total uint64
+	for _, idx := range d.index {
+		if s.MinChunkSize == 0 || idx.Size < s.MinChunkSize {
+			s.MinChunkSize = idx.Size
+		}
+		if idx.Size > s.MaxChunkSize {
+			s.MaxChunkSize = idx.Size
+		}
+		total += idx.Size
+		s.DataDescriptors[idx.DataDesc]++
+	}
+	if s.Records > 0 {
+		s.AvgChunkSize = float64(total) / float64(s.Records)
+	}
+	if d.header.indexLen > 0 {
+		s.DeletedRatio = float64(s.Deleted) / float64(d.header.indexLen)
+	}
+
+	s.LiveBytes = int64(total)
+	s.DeadBytes = s.DataSpaceSize - s.LiveBytes
+	if s.DataSpaceSize > 0 {
+		s.Fragmentation = float64(s.DeadBytes) / float64(s.DataSpaceSize)
+	}
+	s.UnusedIndexBytes = int64(d.header.indexCap-d.header.indexLen) * sizeIndexRec
+	s.OptimizeRecommended = s.Fragmentation > optimizeThreshold
+
+	return s, nil
+}
@@ -0,0 +1,106 @@
+package dataset
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// ViolationKind identifies a kind of invariant violation found by Validate.
+type ViolationKind string
+
+const (
+	ViolationIndexLen     ViolationKind = "index_len"     // index length exceeds capacity
+	ViolationZeroID       ViolationKind = "zero_id"       // index record with zero ID
+	ViolationDuplicateID  ViolationKind = "duplicate_id"  // several live records with the same ID
+	ViolationOutOfBounds  ViolationKind = "out_of_bounds" // chunk lies outside data space
+	ViolationChunkSize    ViolationKind = "chunk_size"    // chunk size fields do not match record size
+	ViolationOverlap      ViolationKind = "overlap"       // live chunks overlap
+	ViolationIndexMissing ViolationKind = "index_missing" // on-disk live record is absent in memory
+)
+
+// Violation describes a broken dataset invariant.
+type Violation struct {
+	Kind    ViolationKind
+	ID      uint32
+	Message string
+}
+
+// Validate checks dataset invariants against the file on disk and reports every violation found.
+// An empty result means the dataset is consistent.
+func (d *Dataset) Validate() ([]Violation, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	var res []Violation
+	add := func(kind ViolationKind, id uint32, format string, args ...any) {
+		res = append(res, Violation{Kind: kind, ID: id, Message: fmt.Sprintf(format, args...)})
+	}
+
+	h := d.header
+	if h.indexLen > h.indexCap {
+		add(ViolationIndexLen, 0, "index length %d exceeds capacity %d", h.indexLen, h.indexCap)
+		return res, nil
+	}
+
+	fi, err := d.f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	dataSpaceSize := fi.Size() - h.dataSpacePos()
+	if dataSpaceSize < 0 {
+		add(ViolationOutOfBounds, 0, "file is shorter than index space: %d bytes", fi.Size())
+		return res, nil
+	}
+
+	buf := make([]byte, h.indexLen*sizeIndexRec)
+	if _, err := d.f.ReadAt(buf, h.size()); err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	var live []index
+	seen := make(map[uint32]bool)
+	for i := uint32(0); i < h.indexLen; i++ {
+		rec := parseIndex(buf[i*sizeIndexRec:])
+		rec.slot = i
+		if rec.ID == 0 {
+			add(ViolationZeroID, 0, "index slot %d has zero ID", i)
+			continue
+		}
+		if rec.isDeleted() {
+			continue
+		}
+		if seen[rec.ID] {
+			add(ViolationDuplicateID, rec.ID, "ID %d has several live records", rec.ID)
+			continue
+		}
+		seen[rec.ID] = true
+		if _, ok := d.index[rec.ID]; !ok {
+			add(ViolationIndexMissing, rec.ID, "live record %d is not in memory index", rec.ID)
+		}
+
+		if rec.Position > uint64(dataSpaceSize) || rec.Size > uint64(dataSpaceSize)-rec.Position {
+			add(ViolationOutOfBounds, rec.ID, "chunk %d at %d size %d exceeds data space size %d", rec.ID, rec.Position, rec.Size, dataSpaceSize)
+			continue
+		}
+		ok, err := chunkValid(d.f, h, &rec, uint64(dataSpaceSize))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			add(ViolationChunkSize, rec.ID, "chunk %d size fields do not match record size %d", rec.ID, rec.Size)
+			continue
+		}
+		live = append(live, rec)
+	}
+
+	slices.SortFunc(live, func(a, b index) int { return cmp.Compare(a.Position, b.Position) })
+	for i := 1; i < len(live); i++ {
+		prev, cur := live[i-1], live[i]
+		if prev.Position+prev.Size > cur.Position {
+			add(ViolationOverlap, cur.ID, "chunk %d overlaps chunk %d", cur.ID, prev.ID)
+		}
+	}
+
+	return res, nil
+}
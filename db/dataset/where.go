@@ -0,0 +1,108 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyAffected is returned when an operation would affect more chunks than allowed
+var ErrTooManyAffected = errors.New("too many chunks affected")
+
+// DeleteOptions configures DeleteWhere.
+type DeleteOptions struct {
+	// MaxAffected is the maximum amount of chunks allowed to be deleted, zero means no limit
+	MaxAffected int
+	// DryRun reports the amount of matching chunks without deleting them
+	DryRun bool
+}
+
+// MetaPatch returns the new meta of chunk c, or nil to leave the chunk unchanged.
+type MetaPatch func(c *Chunk) (Unit, error)
+
+// UpdateMetaWhere replaces meta of chunks yielded by list with the result of patch
+// and returns the amount of changed chunks. The list must load FieldMeta for
+// patch to see the current meta. Patches are computed before any chunk is
+// written, an error from patch leaves the dataset unchanged.
+// With dryRun nothing is written and the amount of chunks that would change is returned.
+// The dataset lock is held from list evaluation until the single sync after
+// the last write, so patches never apply to stale content; list filters,
+// comparators and patch must not call methods of the dataset.
+func (d *Dataset) UpdateMetaWhere(list *ListBuilder, patch MetaPatch, dryRun bool) (int, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	list, err := list.underLock(d)
+	if err != nil {
+		return 0, err
+	}
+	type change struct {
+		id   uint32
+		meta Unit
+	}
+	var changes []change
+	for c, err := range list.Iter() {
+		if err != nil {
+			return 0, err
+		}
+		meta, err := patch(c)
+		if err != nil {
+			return 0, fmt.Errorf("failed to patch chunk %d: %w", c.ID, err)
+		}
+		if meta != nil {
+			changes = append(changes, change{id: c.ID, meta: meta})
+		}
+	}
+	if dryRun || len(changes) == 0 {
+		return len(changes), nil
+	}
+
+	changed := 0
+	for _, ch := range changes {
+		if err := d.update(ch.id, nil, ch.meta, nil); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	if err := d.f.Sync(); err != nil {
+		return changed, fmt.Errorf("failed to sync file: %w", err)
+	}
+	return changed, nil
+}
+
+// DeleteWhere marks chunks yielded by list as deleted and returns their amount.
+// If more than opts.MaxAffected chunks match, nothing is deleted and
+// ErrTooManyAffected is returned with the amount of matching chunks.
+// Records are written with a single sync, data stays in the file until Optimize.
+// The dataset lock is held from list evaluation until the sync, list filters
+// and comparators must not call methods of the dataset.
+func (d *Dataset) DeleteWhere(list *ListBuilder, opts DeleteOptions) (int, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	list, err := list.underLock(d)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := list.ids()
+	if err != nil {
+		return 0, err
+	}
+	if opts.MaxAffected > 0 && len(ids) > opts.MaxAffected {
+		return len(ids), fmt.Errorf("%w: %d chunks match, limit is %d", ErrTooManyAffected, len(ids), opts.MaxAffected)
+	}
+	if opts.DryRun || len(ids) == 0 {
+		return len(ids), nil
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		if err := d.markDeleted(id); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	if err := d.f.Sync(); err != nil {
+		return deleted, fmt.Errorf("failed to sync file: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,188 @@
+// Package querylog stores search queries, their results and user feedback
+// in a dataset file for later relevance tuning and analytics.
+package querylog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/webzak/mindstore/db/dataset"
+)
+
+const (
+	// signature identifies query log datasets
+	signature = 0x514c4f47 // "QLOG"
+	// initial index capacity of a new log
+	initialCap = 1024
+
+	descText    = 1 // query data is text
+	descJSON    = 2 // meta is JSON encoded
+	descFloat32 = 3 // vector is little endian float32 array
+)
+
+// Feedback is a user reaction on a returned item
+type Feedback struct {
+	ItemID uint32 `json:"item"`
+	Label  string `json:"label"`
+	// Date is unix timestamp in seconds
+	Date int64 `json:"date"`
+}
+
+// Entry is a recorded query
+type Entry struct {
+	ID       uint32
+	Date     time.Time
+	Query    []byte
+	Vector   []float32
+	Results  []uint32
+	Feedback []Feedback
+}
+
+// meta is stored in the dataset meta blob
+type meta struct {
+	// Date is the query unix timestamp in seconds, the dataset record date
+	// changes on every update and cannot be used for it
+	Date     int64      `json:"date"`
+	Results  []uint32   `json:"results"`
+	Feedback []Feedback `json:"feedback,omitempty"`
+}
+
+// Log is a persistent query log
+type Log struct {
+	ds *dataset.Dataset
+	// mu serializes meta read-modify-write
+	mu sync.Mutex
+}
+
+// Create creates a new query log file
+func Create(path string) (*Log, error) {
+	ds, err := dataset.NewDataset(path, signature, nil, initialCap)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{ds: ds}, nil
+}
+
+// Open opens an existing query log file
+func Open(path string) (*Log, error) {
+	ds, err := dataset.OpenDataset(path)
+	if err != nil {
+		return nil, err
+	}
+	if ds.Signature() != signature {
+		ds.Close()
+		return nil, fmt.Errorf("not a query log: signature 0x%08x", ds.Signature())
+	}
+	return &Log{ds: ds}, nil
+}
+
+// Close closes the query log
+func (l *Log) Close() error {
+	return l.ds.Close()
+}
+
+// Record stores the query text, its vector (may be nil) and returned item IDs.
+// Returns the ID of the recorded query.
+func (l *Log) Record(query []byte, vector []float32, results []uint32) (uint32, error) {
+	m, err := json.Marshal(meta{Date: time.Now().Unix(), Results: results})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode meta: %w", err)
+	}
+	var vec dataset.Unit
+	if vector != nil {
+		vec = dataset.NewByteUnit(encodeVector(vector), descFloat32)
+	}
+	return l.ds.Append(dataset.NewByteUnit(query, descText), dataset.NewByteUnit(m, descJSON), vec)
+}
+
+// AddFeedback attaches a feedback label for itemID to the recorded query.
+func (l *Log) AddFeedback(queryID, itemID uint32, label string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c, err := l.ds.Read(queryID, dataset.FieldMeta)
+	if err != nil {
+		return err
+	}
+	var m meta
+	if err := json.Unmarshal(c.Meta.Blob(), &m); err != nil {
+		return fmt.Errorf("failed to decode meta: %w", err)
+	}
+	m.Feedback = append(m.Feedback, Feedback{ItemID: itemID, Label: label, Date: time.Now().Unix()})
+	blob, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode meta: %w", err)
+	}
+	return l.ds.Update(queryID, nil, dataset.NewByteUnit(blob, descJSON), nil)
+}
+
+// Get returns the recorded query by ID
+func (l *Log) Get(id uint32) (*Entry, error) {
+	c, err := l.ds.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	return toEntry(c)
+}
+
+// Entries returns all recorded queries in ID order, starting after the given ID.
+func (l *Log) Entries(after uint32, limit int) ([]*Entry, error) {
+	var res []*Entry
+	for c, err := range l.ds.List().After(after).Limit(limit).Load(dataset.FieldData, dataset.FieldMeta, dataset.FieldVector).Iter() {
+		if err != nil {
+			return nil, err
+		}
+		e, err := toEntry(c)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+func toEntry(c *dataset.Chunk) (*Entry, error) {
+	e := &Entry{ID: c.ID}
+	if c.Data != nil {
+		e.Query = c.Data.Blob()
+	}
+	if c.Meta != nil && len(c.Meta.Blob()) > 0 {
+		var m meta
+		if err := json.Unmarshal(c.Meta.Blob(), &m); err != nil {
+			return nil, fmt.Errorf("failed to decode meta: %w", err)
+		}
+		e.Results = m.Results
+		e.Feedback = m.Feedback
+		e.Date = time.Unix(m.Date, 0)
+	}
+	if c.Vector != nil && len(c.Vector.Blob()) > 0 {
+		vec, err := decodeVector(c.Vector.Blob())
+		if err != nil {
+			return nil, err
+		}
+		e.Vector = vec
+	}
+	return e, nil
+}
+
+func encodeVector(v []float32) []byte {
+	buf := make([]byte, len(v)*4)
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(x))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) ([]float32, error) {
+	if len(buf)%4 != 0 {
+		return nil, fmt.Errorf("invalid vector blob size: %d", len(buf))
+	}
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:]))
+	}
+	return v, nil
+}
@@ -0,0 +1,82 @@
+package querylog
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/webzak/mindstore/internal/testutil/assert"
+)
+
+func TestRecordAndFeedback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.log")
+	l, err := Create(path)
+	assert.NilError(t, err)
+
+	id, err := l.Record([]byte("how to bake bread"), []float32{0.5, -1}, []uint32{7, 3, 9})
+	assert.NilError(t, err)
+	_, err = l.Record([]byte("no vector"), nil, nil)
+	assert.NilError(t, err)
+
+	err = l.AddFeedback(id, 3, "click")
+	assert.NilError(t, err)
+	assert.NilError(t, l.Close())
+
+	l, err = Open(path)
+	assert.NilError(t, err)
+	defer l.Close()
+
+	e, err := l.Get(id)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []byte("how to bake bread"), e.Query)
+	assert.DeepEqual(t, []float32{0.5, -1}, e.Vector)
+	assert.DeepEqual(t, []uint32{7, 3, 9}, e.Results)
+	assert.Equal(t, 1, len(e.Feedback))
+	assert.Equal(t, "click", e.Feedback[0].Label)
+
+	entries, err := l.Entries(id, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.DeepEqual(t, []byte("no vector"), entries[0].Query)
+
+	// Units are stored with distinct descriptors
+	c, err := l.ds.Read(id)
+	assert.NilError(t, err)
+	assert.Equal(t, uint8(descText), c.Data.Descriptor())
+	assert.Equal(t, uint8(descJSON), c.Meta.Descriptor())
+	assert.Equal(t, uint8(descFloat32), c.Vector.Descriptor())
+}
+
+func TestFeedbackKeepsDate(t *testing.T) {
+	l, err := Create(filepath.Join(t.TempDir(), "queries.log"))
+	assert.NilError(t, err)
+	defer l.Close()
+
+	id, err := l.Record([]byte("query"), nil, []uint32{1, 2})
+	assert.NilError(t, err)
+	e, err := l.Get(id)
+	assert.NilError(t, err)
+
+	// dates have second resolution, wait for the next second
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second + 10*time.Millisecond)))
+
+	errs := make([]error, 8)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = l.AddFeedback(id, uint32(i), "click")
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NilError(t, err)
+	}
+
+	got, err := l.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, e.Date, got.Date)
+	assert.Equal(t, 8, len(got.Feedback))
+}
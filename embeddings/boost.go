@@ -0,0 +1,135 @@
+package embeddings
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// PinnedPosition is the Position of pinned results inserted by Apply,
+// they were not part of the search and have no data position.
+const PinnedPosition = -1
+
+// BoostRule multiplies the score of matching results by Factor.
+type BoostRule struct {
+	Name   string
+	Match  func(d Distance) bool
+	Factor float32
+}
+
+// Boosts is a post-scoring stage applying boost rules and pinned results.
+type Boosts struct {
+	Rules []BoostRule
+	// Pinned IDs are always returned at the top in the given order
+	Pinned []int
+}
+
+// BoostRuleSpec is the serializable form of a BoostRule. It matches results
+// for which the compile lookup reports Value among the values of Field.
+type BoostRuleSpec struct {
+	Name   string  `json:"name"`
+	Field  string  `json:"field"`
+	Value  string  `json:"value"`
+	Factor float32 `json:"factor"`
+}
+
+// BoostConfig is the serializable form of Boosts kept in the collection config.
+type BoostConfig struct {
+	Rules  []BoostRuleSpec `json:"rules,omitempty"`
+	Pinned []int           `json:"pinned,omitempty"`
+}
+
+// FieldLookup returns values of field for result id, e.g. tags from chunk
+// meta or names of flags set on the chunk.
+type FieldLookup func(id int, field string) []string
+
+// Compile builds Boosts with rules matching through lookup.
+func (c *BoostConfig) Compile(lookup FieldLookup) (*Boosts, error) {
+	if len(c.Rules) > 0 && lookup == nil {
+		return nil, fmt.Errorf("field lookup is required for boost rules")
+	}
+	b := &Boosts{
+		Rules:  make([]BoostRule, len(c.Rules)),
+		Pinned: slices.Clone(c.Pinned),
+	}
+	for i, spec := range c.Rules {
+		if spec.Field == "" {
+			return nil, fmt.Errorf("boost rule %d (%s) has no field", i, spec.Name)
+		}
+		b.Rules[i] = BoostRule{
+			Name: spec.Name,
+			Match: func(d Distance) bool {
+				return slices.Contains(lookup(d.ID, spec.Field), spec.Value)
+			},
+			Factor: spec.Factor,
+		}
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Validate checks that rules are usable.
+func (b *Boosts) Validate() error {
+	for i, r := range b.Rules {
+		if r.Match == nil {
+			return fmt.Errorf("boost rule %d (%s) has no match function", i, r.Name)
+		}
+		if r.Factor < 0 {
+			return fmt.Errorf("boost rule %d (%s) has negative factor %v", i, r.Name, r.Factor)
+		}
+	}
+	return nil
+}
+
+// Apply returns results with boosted scores ordered by descending score,
+// preceded by pinned results. Factors of all matching rules are multiplied.
+// Pinned IDs missing from res are inserted with zero Value and PinnedPosition.
+// The results are limited by limit, 0 means all. res is not modified.
+func (b *Boosts) Apply(res []Distance, limit int) []Distance {
+	pinnedAt := make(map[int]int, len(b.Pinned))
+	for i, id := range b.Pinned {
+		if _, ok := pinnedAt[id]; !ok {
+			pinnedAt[id] = i
+		}
+	}
+
+	pinned := make([]Distance, len(b.Pinned))
+	found := make([]bool, len(b.Pinned))
+	rest := make([]Distance, 0, len(res))
+	for _, d := range res {
+		for _, r := range b.Rules {
+			if r.Match(d) {
+				d.Value *= r.Factor
+			}
+		}
+		if i, ok := pinnedAt[d.ID]; ok {
+			if !found[i] {
+				pinned[i] = d
+				found[i] = true
+			}
+			continue
+		}
+		rest = append(rest, d)
+	}
+	sort.SliceStable(rest, func(i, j int) bool {
+		return rest[i].Value > rest[j].Value
+	})
+
+	out := make([]Distance, 0, len(pinned)+len(rest))
+	for i, id := range b.Pinned {
+		if pinnedAt[id] != i {
+			continue // duplicate pinned ID
+		}
+		if !found[i] {
+			pinned[i] = Distance{ID: id, Position: PinnedPosition}
+		}
+		out = append(out, pinned[i])
+	}
+	out = append(out, rest...)
+	if limit > 0 && len(out) > limit {
+		return out[:limit]
+	}
+	return out
+}
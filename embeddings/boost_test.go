@@ -0,0 +1,104 @@
+package embeddings
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBoosts(t *testing.T) {
+	res := []Distance{
+		{ID: 1, Value: 0.9, Position: 1},
+		{ID: 2, Value: 0.8, Position: 2},
+		{ID: 3, Value: 0.7, Position: 3},
+		{ID: 4, Value: 0.6, Position: 4},
+	}
+	official := map[int]bool{3: true}
+	b := &Boosts{
+		Rules: []BoostRule{
+			{Name: "official", Match: func(d Distance) bool { return official[d.ID] }, Factor: 2},
+		},
+		Pinned: []int{4, 9, 4},
+	}
+	if err := b.Validate(); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+
+	out := b.Apply(res, 0)
+	var ids []int
+	for _, d := range out {
+		ids = append(ids, d.ID)
+	}
+	if !reflect.DeepEqual(ids, []int{4, 9, 3, 1, 2}) {
+		t.Errorf("unexpected order: %v", ids)
+	}
+	if out[1].Value != 0 || out[1].Position != PinnedPosition {
+		t.Errorf("unexpected inserted pinned result: %v", out[1])
+	}
+	if out[0].Position != 4 {
+		t.Errorf("expected found pinned result to keep its position, got %v", out[0])
+	}
+	if out[2].Value != 1.4 {
+		t.Errorf("expected boosted value 1.4, got %v", out[2].Value)
+	}
+	if res[2].Value != 0.7 {
+		t.Error("input was modified")
+	}
+
+	if out := b.Apply(res, 3); len(out) != 3 {
+		t.Errorf("expected 3 results, got %d", len(out))
+	}
+
+	b.Rules[0].Match = nil
+	if err := b.Validate(); err == nil {
+		t.Error("expected error for rule without match function")
+	}
+}
+
+func TestBoostConfigCompile(t *testing.T) {
+	var cfg BoostConfig
+	blob := []byte(`{"rules":[{"name":"official","field":"tag","value":"official","factor":2}],"pinned":[4]}`)
+	if err := json.Unmarshal(blob, &cfg); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	tags := map[int][]string{2: {"draft", "official"}}
+	b, err := cfg.Compile(func(id int, field string) []string {
+		if field != "tag" {
+			return nil
+		}
+		return tags[id]
+	})
+	if err != nil {
+		t.Fatalf("Compile returned error: %v", err)
+	}
+
+	res := []Distance{
+		{ID: 1, Value: 0.9, Position: 1},
+		{ID: 2, Value: 0.6, Position: 2},
+		{ID: 4, Value: 0.5, Position: 4},
+	}
+	var ids []int
+	for _, d := range b.Apply(res, 0) {
+		ids = append(ids, d.ID)
+	}
+	if !reflect.DeepEqual(ids, []int{4, 2, 1}) {
+		t.Errorf("unexpected order: %v", ids)
+	}
+
+	// Config survives a round trip
+	out, err := json.Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	if string(out) != string(blob) {
+		t.Errorf("unexpected config %s", out)
+	}
+
+	if _, err := cfg.Compile(nil); err == nil {
+		t.Error("expected error without lookup")
+	}
+	cfg.Rules[0].Field = ""
+	if _, err := cfg.Compile(func(int, string) []string { return nil }); err == nil {
+		t.Error("expected error for rule without field")
+	}
+}
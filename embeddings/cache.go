@@ -0,0 +1,124 @@
+package embeddings
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// CachedEmbedder wraps an Embedder with an LRU cache of vectors keyed by input data.
+// It is safe for concurrent use.
+type CachedEmbedder struct {
+	mu     sync.Mutex
+	e      Embedder
+	size   int
+	ll     *list.List
+	items  map[string]*list.Element
+	hits   uint64
+	misses uint64
+}
+
+type cacheEntry struct {
+	key    string
+	vector []float32
+}
+
+// NewCached creates an Embedder caching up to size vectors produced by e.
+func NewCached(e Embedder, size int) *CachedEmbedder {
+	return &CachedEmbedder{
+		e:     e,
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Embed returns cached vector for data or calls the wrapped embedder.
+func (c *CachedEmbedder) Embed(ctx context.Context, data []byte) ([]float32, error) {
+	key := string(data)
+	if vec, ok := c.get(key); ok {
+		return vec, nil
+	}
+	vec, err := c.e.Embed(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, vec)
+	return clone(vec), nil
+}
+
+// EmbedBatch embeds only data items missing in cache with a single batch call.
+func (c *CachedEmbedder) EmbedBatch(ctx context.Context, chunks [][]byte) ([][]float32, error) {
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	results := make([][]float32, len(chunks))
+	var missing [][]byte
+	var missingPos []int
+	for i, d := range chunks {
+		if vec, ok := c.get(string(d)); ok {
+			results[i] = vec
+			continue
+		}
+		missing = append(missing, d)
+		missingPos = append(missingPos, i)
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	vecs, err := c.e.EmbedBatch(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) != len(missing) {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d inputs", len(vecs), len(missing))
+	}
+	for j, vec := range vecs {
+		c.put(string(missing[j]), vec)
+		results[missingPos[j]] = clone(vec)
+	}
+	return results, nil
+}
+
+// Stats returns the amount of cache hits and misses.
+func (c *CachedEmbedder) Stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// get returns a copy of cached vector for key
+func (c *CachedEmbedder) get(key string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.ll.MoveToFront(el)
+	return clone(el.Value.(*cacheEntry).vector), true
+}
+
+// put stores a copy of vector for key evicting least recently used entries
+func (c *CachedEmbedder) put(key string, vec []float32) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheEntry).vector = clone(vec)
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, vector: clone(vec)})
+	for c.ll.Len() > c.size {
+		el := c.ll.Back()
+		c.ll.Remove(el)
+		delete(c.items, el.Value.(*cacheEntry).key)
+	}
+}
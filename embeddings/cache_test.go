@@ -0,0 +1,83 @@
+package embeddings
+
+import (
+	"context"
+	"testing"
+)
+
+// countingEmbedder returns vectors derived from data length and counts calls
+type countingEmbedder struct {
+	calls int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, data []byte) ([]float32, error) {
+	e.calls++
+	return []float32{float32(len(data))}, nil
+}
+
+func (e *countingEmbedder) EmbedBatch(ctx context.Context, chunks [][]byte) ([][]float32, error) {
+	res := make([][]float32, len(chunks))
+	for i, d := range chunks {
+		res[i], _ = e.Embed(ctx, d)
+	}
+	return res, nil
+}
+
+func TestCachedEmbedder(t *testing.T) {
+	inner := &countingEmbedder{}
+	c := NewCached(inner, 2)
+	ctx := context.Background()
+
+	c.Embed(ctx, []byte("a"))
+	vec, _ := c.Embed(ctx, []byte("a"))
+	if inner.calls != 1 {
+		t.Errorf("Expected 1 call, got %d", inner.calls)
+	}
+	// Modifying returned vector must not affect cache
+	vec[0] = 100
+	vec, _ = c.Embed(ctx, []byte("a"))
+	if vec[0] != 1 {
+		t.Errorf("Expected cached value 1, got %v", vec[0])
+	}
+
+	res, err := c.EmbedBatch(ctx, [][]byte{[]byte("a"), []byte("bb"), []byte("ccc")})
+	if err != nil {
+		t.Fatalf("EmbedBatch returned error: %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", inner.calls)
+	}
+	if res[1][0] != 2 || res[2][0] != 3 {
+		t.Errorf("Unexpected batch result %v", res)
+	}
+
+	// "a" is evicted as least recently used
+	c.Embed(ctx, []byte("a"))
+	if inner.calls != 4 {
+		t.Errorf("Expected 4 calls, got %d", inner.calls)
+	}
+
+	hits, misses := c.Stats()
+	if hits != 3 || misses != 4 {
+		t.Errorf("Expected 3 hits and 4 misses, got %d and %d", hits, misses)
+	}
+}
+
+// shortEmbedder returns a batch result of wrong size
+type shortEmbedder struct {
+	countingEmbedder
+	extra int
+}
+
+func (e *shortEmbedder) EmbedBatch(ctx context.Context, chunks [][]byte) ([][]float32, error) {
+	return make([][]float32, len(chunks)+e.extra), nil
+}
+
+func TestCachedEmbedderBatchSizeMismatch(t *testing.T) {
+	for _, extra := range []int{-1, 1} {
+		c := NewCached(&shortEmbedder{extra: extra}, 4)
+		if _, err := c.EmbedBatch(context.Background(), [][]byte{[]byte("a"), []byte("b")}); err == nil {
+			t.Errorf("Expected error for %d extra vectors", extra)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrDimensionMismatch is returned when embedder produces vectors of unexpected size
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// checkProbe is the input embedded by Check
+var checkProbe = []byte("dimension check")
+
+// Check pings the embedder with a probe input and verifies that the produced
+// vector has the expected dimension. It is meant to be called when a store
+// with a configured embedder is opened, before any vectors are written.
+func Check(ctx context.Context, e Embedder, dim int) error {
+	vec, err := e.Embed(ctx, checkProbe)
+	if err != nil {
+		return fmt.Errorf("embedder health check failed: %w", err)
+	}
+	if len(vec) != dim {
+		return fmt.Errorf("%w: expected: %d, actual: %d", ErrDimensionMismatch, dim, len(vec))
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	if err := Check(ctx, NewMock(8), 8); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := Check(ctx, NewMock(8), 16); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected ErrDimensionMismatch, got %v", err)
+	}
+
+	failing := embedFunc(func(ctx context.Context) error { return errors.New("down") })
+	if err := Check(ctx, failing, 8); err == nil {
+		t.Error("Expected error from failing embedder")
+	}
+}
@@ -0,0 +1,99 @@
+package embeddings
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+)
+
+const (
+	lshTables = 8  // amount of independent hash tables
+	lshBits   = 10 // hyperplanes per table
+	lshSeed   = 1  // fixed seed keeps results reproducible
+)
+
+// NearDuplicates finds groups of rows whose cosine similarity to another
+// row in the group is at least threshold. Candidate pairs are found with
+// random hyperplane LSH to avoid comparing every pair, so results are
+// approximate: a pair with similarity close to threshold may be missed.
+// Each returned group contains row indexes in ascending order; groups are
+// ordered by their first index. Rows without duplicates are omitted.
+func NearDuplicates(rows [][]float32, threshold float32) ([][]int, error) {
+	if len(rows) < 2 {
+		return nil, nil
+	}
+	dim := len(rows[0])
+	for _, row := range rows {
+		if len(row) != dim {
+			return nil, fmt.Errorf("vector size mismatch: expected: %d, actual: %d", dim, len(row))
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(lshSeed))
+	parent := make([]int, len(rows))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	planes := make([][]float32, lshBits)
+	for t := 0; t < lshTables; t++ {
+		for b := range planes {
+			planes[b] = make([]float32, dim)
+			for j := range planes[b] {
+				planes[b][j] = float32(rnd.NormFloat64())
+			}
+		}
+		buckets := make(map[uint32][]int)
+		for i, row := range rows {
+			var key uint32
+			for b, p := range planes {
+				if dot(row, p) >= 0 {
+					key |= 1 << b
+				}
+			}
+			buckets[key] = append(buckets[key], i)
+		}
+		for _, members := range buckets {
+			for x := 0; x < len(members); x++ {
+				for y := x + 1; y < len(members); y++ {
+					a, b := members[x], members[y]
+					if find(a) == find(b) {
+						continue
+					}
+					if CosineSim(rows[a], rows[b]) >= threshold {
+						parent[find(a)] = find(b)
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range rows {
+		r := find(i)
+		groups[r] = append(groups[r], i)
+	}
+	var res [][]int
+	for _, g := range groups {
+		if len(g) > 1 {
+			res = append(res, g)
+		}
+	}
+	slices.SortFunc(res, func(a, b []int) int { return a[0] - b[0] })
+	return res, nil
+}
+
+func dot(a, b []float32) float32 {
+	var s float32
+	for i, v := range a {
+		s += v * b[i]
+	}
+	return s
+}
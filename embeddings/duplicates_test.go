@@ -0,0 +1,32 @@
+package embeddings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNearDuplicates(t *testing.T) {
+	rows := [][]float32{
+		{1.0, 0.0, 0.0},
+		{0.0, 1.0, 0.0},
+		{0.99, 0.01, 0.0},
+		{0.0, 0.0, 1.0},
+		{0.0, 0.98, 0.02},
+	}
+
+	groups, err := NearDuplicates(rows, 0.99)
+	if err != nil {
+		t.Fatalf("NearDuplicates returned error: %v", err)
+	}
+	expected := [][]int{{0, 2}, {1, 4}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected %v, got %v", expected, groups)
+	}
+}
+
+func TestNearDuplicatesSizeMismatch(t *testing.T) {
+	_, err := NearDuplicates([][]float32{{1, 0}, {1, 0, 0}}, 0.9)
+	if err == nil {
+		t.Fatal("expected error for size mismatch, got nil")
+	}
+}
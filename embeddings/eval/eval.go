@@ -0,0 +1,124 @@
+// Package eval measures approximate search quality against exact search.
+package eval
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/webzak/mindstore/embeddings"
+)
+
+// SearchFunc returns up to k results most similar to query
+type SearchFunc func(query []float32, k int) ([]embeddings.Distance, error)
+
+// Latency describes a distribution of search durations
+type Latency struct {
+	Mean time.Duration
+	P50  time.Duration
+	P95  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+// Report contains evaluation results
+type Report struct {
+	Queries int
+	K       int
+	// Recall is the mean fraction of exact top-k IDs found by approximate search
+	Recall        float64
+	ExactLatency  Latency
+	ApproxLatency Latency
+}
+
+// SampleQueries picks n distinct rows as queries using seed for reproducibility.
+func SampleQueries(rows [][]float32, n int, seed int64) [][]float32 {
+	if n > len(rows) {
+		n = len(rows)
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	perm := rnd.Perm(len(rows))
+	queries := make([][]float32, n)
+	for i := range queries {
+		queries[i] = rows[perm[i]]
+	}
+	return queries
+}
+
+// Run executes every query with exact and approximate search and reports recall@k and latencies.
+func Run(queries [][]float32, k int, exact, approx SearchFunc) (*Report, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no queries")
+	}
+
+	exactTimes := make([]time.Duration, len(queries))
+	approxTimes := make([]time.Duration, len(queries))
+	var recallSum float64
+
+	for i, q := range queries {
+		start := time.Now()
+		want, err := exact(q, k)
+		if err != nil {
+			return nil, fmt.Errorf("exact search failed for query %d: %w", i, err)
+		}
+		exactTimes[i] = time.Since(start)
+
+		start = time.Now()
+		got, err := approx(q, k)
+		if err != nil {
+			return nil, fmt.Errorf("approximate search failed for query %d: %w", i, err)
+		}
+		approxTimes[i] = time.Since(start)
+
+		recallSum += Recall(want, got)
+	}
+
+	return &Report{
+		Queries:       len(queries),
+		K:             k,
+		Recall:        recallSum / float64(len(queries)),
+		ExactLatency:  latency(exactTimes),
+		ApproxLatency: latency(approxTimes),
+	}, nil
+}
+
+// Recall returns the fraction of IDs in want which are present in got.
+// Returns 1 if want is empty.
+func Recall(want, got []embeddings.Distance) float64 {
+	if len(want) == 0 {
+		return 1
+	}
+	ids := make(map[int]struct{}, len(got))
+	for _, d := range got {
+		ids[d.ID] = struct{}{}
+	}
+	found := 0
+	for _, d := range want {
+		if _, ok := ids[d.ID]; ok {
+			found++
+		}
+	}
+	return float64(found) / float64(len(want))
+}
+
+func latency(times []time.Duration) Latency {
+	slices.Sort(times)
+	var total time.Duration
+	for _, t := range times {
+		total += t
+	}
+	pct := func(p float64) time.Duration {
+		return times[int(p*float64(len(times)-1))]
+	}
+	return Latency{
+		Mean: total / time.Duration(len(times)),
+		P50:  pct(0.50),
+		P95:  pct(0.95),
+		P99:  pct(0.99),
+		Max:  times[len(times)-1],
+	}
+}
@@ -0,0 +1,47 @@
+package eval
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/webzak/mindstore/embeddings"
+)
+
+func TestRecall(t *testing.T) {
+	want := []embeddings.Distance{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	got := []embeddings.Distance{{ID: 2}, {ID: 4}, {ID: 7}}
+	if r := Recall(want, got); r != 0.5 {
+		t.Errorf("Expected recall 0.5, got %v", r)
+	}
+}
+
+func TestRunWithIVF(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	rows := make([][]float32, 200)
+	for i := range rows {
+		rows[i] = []float32{rnd.Float32(), rnd.Float32(), rnd.Float32()}
+	}
+	ivf, err := embeddings.NewIVF(rows, 4, embeddings.KMeansOptions{Seed: 1})
+	if err != nil {
+		t.Fatalf("NewIVF returned error: %v", err)
+	}
+
+	exact := func(q []float32, k int) ([]embeddings.Distance, error) {
+		return embeddings.CosineSimRanking(rows, q, embeddings.SortDesc, k)
+	}
+	full := func(q []float32, k int) ([]embeddings.Distance, error) {
+		return ivf.Search(q, 0, k)
+	}
+
+	report, err := Run(SampleQueries(rows, 10, 1), 5, exact, full)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if report.Queries != 10 {
+		t.Errorf("Expected 10 queries, got %d", report.Queries)
+	}
+	// Probing all lists is exhaustive, recall must be perfect
+	if report.Recall != 1 {
+		t.Errorf("Expected recall 1, got %v", report.Recall)
+	}
+}
@@ -0,0 +1,155 @@
+package embeddings
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Groups keeps a mean vector per group of rows, updated on every add and
+// remove, and searches groups first and then rows of the best groups.
+// It is a cheap hierarchical search for corpora where rows are chunks of
+// documents and a group is a document.
+type Groups struct {
+	dim    int
+	groups map[int]*group
+}
+
+type group struct {
+	// sum is kept in float64 so that repeated add/remove does not drift
+	sum  []float64
+	rows map[int][]float32
+}
+
+// GroupHit is a group search result.
+type GroupHit struct {
+	Group int
+	// Value is the cosine similarity between query and group centroid
+	Value float32
+}
+
+// NewGroups creates an empty group index for vectors of size dim.
+func NewGroups(dim int) (*Groups, error) {
+	if dim <= 0 {
+		return nil, fmt.Errorf("vector size must be positive")
+	}
+	return &Groups{dim: dim, groups: make(map[int]*group)}, nil
+}
+
+// Add adds row with id to group g. IDs must be unique within a group,
+// the index keeps a reference to row, it must not be modified.
+func (x *Groups) Add(g, id int, row []float32) error {
+	if len(row) != x.dim {
+		return fmt.Errorf("vector size mismatch: expected: %d, actual: %d", x.dim, len(row))
+	}
+	gr, ok := x.groups[g]
+	if !ok {
+		gr = &group{sum: make([]float64, x.dim), rows: make(map[int][]float32)}
+		x.groups[g] = gr
+	}
+	if _, ok := gr.rows[id]; ok {
+		return fmt.Errorf("row %d already exists in group %d", id, g)
+	}
+	gr.rows[id] = row
+	for i, v := range row {
+		gr.sum[i] += float64(v)
+	}
+	return nil
+}
+
+// Remove removes row with id from group g and reports whether it was found.
+// Empty groups are dropped.
+func (x *Groups) Remove(g, id int) bool {
+	gr, ok := x.groups[g]
+	if !ok {
+		return false
+	}
+	row, ok := gr.rows[id]
+	if !ok {
+		return false
+	}
+	delete(gr.rows, id)
+	if len(gr.rows) == 0 {
+		delete(x.groups, g)
+		return true
+	}
+	for i, v := range row {
+		gr.sum[i] -= float64(v)
+	}
+	return true
+}
+
+// Len returns the amount of groups.
+func (x *Groups) Len() int {
+	return len(x.groups)
+}
+
+// Centroid returns the mean vector of group g.
+func (x *Groups) Centroid(g int) ([]float32, bool) {
+	gr, ok := x.groups[g]
+	if !ok {
+		return nil, false
+	}
+	return gr.centroid(), true
+}
+
+func (gr *group) centroid() []float32 {
+	c := make([]float32, len(gr.sum))
+	n := float64(len(gr.rows))
+	for i, s := range gr.sum {
+		c[i] = float32(s / n)
+	}
+	return c
+}
+
+// SearchGroups returns groups ordered by descending cosine similarity between
+// vector and group centroid, limited by limit, 0 means return all.
+func (x *Groups) SearchGroups(vector []float32, limit int) ([]GroupHit, error) {
+	if len(vector) != x.dim {
+		return nil, fmt.Errorf("vector size mismatch: expected: %d, actual: %d", x.dim, len(vector))
+	}
+	res := make([]GroupHit, 0, len(x.groups))
+	for g, gr := range x.groups {
+		res = append(res, GroupHit{Group: g, Value: CosineSim(gr.centroid(), vector)})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Value != res[j].Value {
+			return res[i].Value > res[j].Value
+		}
+		return res[i].Group < res[j].Group
+	})
+	if limit > 0 && len(res) > limit {
+		return res[:limit], nil
+	}
+	return res, nil
+}
+
+// Search finds the ngroups groups closest to vector and ranks their rows by
+// cosine similarity. Distance ID is the row id and Position is its group.
+// The results are ordered by descending similarity and limited by limit,
+// 0 means return all scanned.
+func (x *Groups) Search(vector []float32, ngroups, limit int) ([]Distance, error) {
+	hits, err := x.SearchGroups(vector, ngroups)
+	if err != nil {
+		return nil, err
+	}
+	var res []Distance
+	for _, h := range hits {
+		for id, row := range x.groups[h.Group].rows {
+			res = append(res, Distance{
+				ID:       id,
+				Value:    CosineSim(row, vector),
+				Position: h.Group,
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Value != res[j].Value {
+			return res[i].Value > res[j].Value
+		}
+		return res[i].ID < res[j].ID
+	})
+	if limit > 0 && len(res) > limit {
+		return res[:limit], nil
+	}
+	return res, nil
+}
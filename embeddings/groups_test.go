@@ -0,0 +1,63 @@
+package embeddings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	x, err := NewGroups(2)
+	if err != nil {
+		t.Fatalf("NewGroups returned error: %v", err)
+	}
+
+	// group 1 points along x axis, group 2 along y axis
+	x.Add(1, 10, []float32{1, 0})
+	x.Add(1, 11, []float32{1, 0.2})
+	x.Add(2, 20, []float32{0, 1})
+	x.Add(2, 21, []float32{0.2, 1})
+
+	c, ok := x.Centroid(1)
+	if !ok || !reflect.DeepEqual(c, []float32{1, 0.1}) {
+		t.Errorf("unexpected centroid: %v", c)
+	}
+
+	if err := x.Add(1, 10, []float32{1, 0}); err == nil {
+		t.Error("expected error for duplicate row")
+	}
+	if err := x.Add(1, 12, []float32{1}); err == nil {
+		t.Error("expected error for vector size mismatch")
+	}
+
+	hits, err := x.SearchGroups([]float32{0.1, 1}, 0)
+	if err != nil {
+		t.Fatalf("SearchGroups returned error: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Group != 2 {
+		t.Errorf("unexpected group hits: %v", hits)
+	}
+
+	res, err := x.Search([]float32{1, 0.05}, 1, 0)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(res) != 2 || res[0].ID != 10 || res[1].ID != 11 || res[0].Position != 1 {
+		t.Errorf("unexpected results: %v", res)
+	}
+
+	// centroid follows removal
+	if !x.Remove(1, 11) {
+		t.Error("expected row to be removed")
+	}
+	c, _ = x.Centroid(1)
+	if !reflect.DeepEqual(c, []float32{1, 0}) {
+		t.Errorf("unexpected centroid after remove: %v", c)
+	}
+	x.Remove(1, 10)
+	if _, ok := x.Centroid(1); ok || x.Len() != 1 {
+		t.Error("expected empty group to be dropped")
+	}
+	if x.Remove(1, 10) {
+		t.Error("expected remove of missing row to fail")
+	}
+}
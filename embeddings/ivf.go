@@ -0,0 +1,88 @@
+package embeddings
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// IVF is an inverted file index: rows are partitioned by nearest KMeans
+// centroid and search scans only lists of the nprobe closest centroids.
+type IVF struct {
+	centroids [][]float32
+	lists     [][]int
+	rows      [][]float32
+}
+
+// NewIVF builds an IVF index with nlist partitions over rows.
+// The index keeps references to the row vectors, they must not be modified.
+// The rows slice itself is clipped, so Add never writes into its backing array.
+func NewIVF(rows [][]float32, nlist int, opts KMeansOptions) (*IVF, error) {
+	clusters, err := KMeans(rows, nlist, opts)
+	if err != nil {
+		return nil, err
+	}
+	ivf := &IVF{
+		centroids: clusters.Centroids,
+		lists:     make([][]int, nlist),
+		rows:      slices.Clip(rows),
+	}
+	for i, c := range clusters.Assignments {
+		ivf.lists[c] = append(ivf.lists[c], i)
+	}
+	return ivf, nil
+}
+
+// Add appends a row to the index and returns its ID.
+// Centroids are not updated, rebuild the index when data distribution changes.
+func (x *IVF) Add(row []float32) (int, error) {
+	if len(row) != len(x.centroids[0]) {
+		return 0, fmt.Errorf("vector size mismatch: expected: %d, actual: %d", len(x.centroids[0]), len(row))
+	}
+	id := len(x.rows)
+	x.rows = append(x.rows, row)
+	c := nearest(x.centroids, row)
+	x.lists[c] = append(x.lists[c], id)
+	return id, nil
+}
+
+// Search returns rows most similar to vector by cosine similarity, scanning
+// the nprobe lists with closest centroids. The results are ordered by
+// descending similarity and limited by limit, 0 means return all scanned.
+func (x *IVF) Search(vector []float32, nprobe, limit int) ([]Distance, error) {
+	if len(vector) != len(x.centroids[0]) {
+		return nil, fmt.Errorf("vector size mismatch: expected: %d, actual: %d", len(x.centroids[0]), len(vector))
+	}
+	if nprobe <= 0 || nprobe > len(x.centroids) {
+		nprobe = len(x.centroids)
+	}
+
+	// Order centroids by distance to query
+	order := make([]int, len(x.centroids))
+	dist := make([]float32, len(x.centroids))
+	for c, centroid := range x.centroids {
+		order[c] = c
+		dist[c] = sqDist(vector, centroid)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return dist[order[i]] < dist[order[j]]
+	})
+
+	var res []Distance
+	for _, c := range order[:nprobe] {
+		for _, id := range x.lists[c] {
+			res = append(res, Distance{
+				ID:       id,
+				Value:    CosineSim(x.rows[id], vector),
+				Position: id,
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Value > res[j].Value
+	})
+	if limit > 0 && len(res) > limit {
+		return res[:limit], nil
+	}
+	return res, nil
+}
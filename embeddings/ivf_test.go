@@ -0,0 +1,82 @@
+package embeddings
+
+import (
+	"testing"
+
+	"github.com/webzak/mindstore/testutil/fixtures"
+)
+
+func TestIVF(t *testing.T) {
+	rows := [][]float32{
+		{1.0, 0.1},
+		{1.0, 0.0},
+		{0.1, 1.0},
+		{0.0, 1.0},
+	}
+
+	ivf, err := NewIVF(rows, 2, KMeansOptions{Seed: 1})
+	if err != nil {
+		t.Fatalf("NewIVF returned error: %v", err)
+	}
+
+	id, err := ivf.Add([]float32{0.9, 0.0})
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	if id != 4 {
+		t.Errorf("Expected id 4, got %d", id)
+	}
+
+	res, err := ivf.Search([]float32{1.0, 0.0}, 1, 0)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(res) != 3 {
+		t.Fatalf("Expected 3 results from one probed list, got %d", len(res))
+	}
+	for _, d := range res {
+		if d.ID != 0 && d.ID != 1 && d.ID != 4 {
+			t.Errorf("Unexpected result ID %d", d.ID)
+		}
+	}
+
+	res, err = ivf.Search([]float32{1.0, 0.0}, 0, 2)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(res))
+	}
+}
+
+// BenchmarkIVFSearch benchmarks IVF search over generated vectors
+func TestIVFAddKeepsCallerRows(t *testing.T) {
+	backing := make([][]float32, 4, 8)
+	copy(backing, [][]float32{{1, 0.1}, {1, 0}, {0.1, 1}, {0, 1}})
+	spare := backing[:5]
+
+	ivf, err := NewIVF(backing, 2, KMeansOptions{Seed: 1})
+	if err != nil {
+		t.Fatalf("NewIVF returned error: %v", err)
+	}
+	if _, err := ivf.Add([]float32{1, 1}); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	if spare[4] != nil {
+		t.Errorf("Add wrote into caller backing array: %v", spare[4])
+	}
+}
+
+func BenchmarkIVFSearch(b *testing.B) {
+	rows := fixtures.Vectors(5000, 64, 1)
+	queries := fixtures.Vectors(100, 64, 2)
+	ivf, err := NewIVF(rows, 50, KMeansOptions{Seed: 1})
+	if err != nil {
+		b.Fatalf("NewIVF returned error: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ivf.Search(queries[i%len(queries)], 8, 10)
+	}
+}
@@ -0,0 +1,148 @@
+package embeddings
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// KMeansOptions configures KMeans clustering
+type KMeansOptions struct {
+	// MaxIter limits the amount of iterations, 0 means 100
+	MaxIter int
+	// Seed makes clustering reproducible
+	Seed int64
+}
+
+// Clusters represents KMeans clustering result
+type Clusters struct {
+	// Centroids contains k cluster centers
+	Centroids [][]float32
+	// Assignments contains cluster number for each row
+	Assignments []int
+}
+
+// KMeans clusters rows into k clusters using Euclidean distance
+// with k-means++ initialization.
+func KMeans(rows [][]float32, k int, opts KMeansOptions) (*Clusters, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+	if len(rows) < k {
+		return nil, fmt.Errorf("not enough rows for %d clusters: %d", k, len(rows))
+	}
+	dim := len(rows[0])
+	for _, row := range rows {
+		if len(row) != dim {
+			return nil, fmt.Errorf("vector size mismatch: expected: %d, actual: %d", dim, len(row))
+		}
+	}
+	maxIter := opts.MaxIter
+	if maxIter <= 0 {
+		maxIter = 100
+	}
+
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	centroids := initCentroids(rows, k, rnd)
+	assignments := make([]int, len(rows))
+	for i := range assignments {
+		assignments[i] = -1
+	}
+
+	for iter := 0; iter < maxIter; iter++ {
+		changed := false
+		for i, row := range rows {
+			c := nearest(centroids, row)
+			if c != assignments[i] {
+				assignments[i] = c
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		// Recompute centroids as means of assigned rows
+		counts := make([]int, k)
+		sums := make([][]float32, k)
+		for c := range sums {
+			sums[c] = make([]float32, dim)
+		}
+		for i, row := range rows {
+			c := assignments[i]
+			counts[c]++
+			for j, v := range row {
+				sums[c][j] += v
+			}
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				// Keep centroid of empty cluster unchanged
+				continue
+			}
+			for j := range sums[c] {
+				sums[c][j] /= float32(counts[c])
+			}
+			centroids[c] = sums[c]
+		}
+	}
+
+	return &Clusters{Centroids: centroids, Assignments: assignments}, nil
+}
+
+// initCentroids chooses initial centroids using k-means++
+func initCentroids(rows [][]float32, k int, rnd *rand.Rand) [][]float32 {
+	centroids := make([][]float32, 0, k)
+	centroids = append(centroids, clone(rows[rnd.Intn(len(rows))]))
+	dist := make([]float64, len(rows))
+	for len(centroids) < k {
+		var total float64
+		for i, row := range rows {
+			dist[i] = float64(sqDist(row, centroids[nearest(centroids, row)]))
+			total += dist[i]
+		}
+		if total == 0 {
+			// All rows coincide with centroids, pick any row
+			centroids = append(centroids, clone(rows[rnd.Intn(len(rows))]))
+			continue
+		}
+		target := rnd.Float64() * total
+		chosen := len(rows) - 1
+		for i, d := range dist {
+			target -= d
+			if target < 0 {
+				chosen = i
+				break
+			}
+		}
+		centroids = append(centroids, clone(rows[chosen]))
+	}
+	return centroids
+}
+
+// nearest returns index of centroid closest to row
+func nearest(centroids [][]float32, row []float32) int {
+	best := 0
+	bestDist := sqDist(row, centroids[0])
+	for c := 1; c < len(centroids); c++ {
+		if d := sqDist(row, centroids[c]); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// sqDist calculates squared Euclidean distance between two vectors
+func sqDist(a, b []float32) float32 {
+	var s float32
+	for i, v := range a {
+		d := v - b[i]
+		s += d * d
+	}
+	return s
+}
+
+func clone(v []float32) []float32 {
+	res := make([]float32, len(v))
+	copy(res, v)
+	return res
+}
@@ -0,0 +1,44 @@
+package embeddings
+
+import "testing"
+
+func TestKMeans(t *testing.T) {
+	rows := [][]float32{
+		{0.0, 0.1},
+		{0.1, 0.0},
+		{0.0, 0.0},
+		{10.0, 10.1},
+		{10.1, 10.0},
+		{10.0, 10.0},
+	}
+
+	res, err := KMeans(rows, 2, KMeansOptions{Seed: 42})
+	if err != nil {
+		t.Fatalf("KMeans returned error: %v", err)
+	}
+	if len(res.Centroids) != 2 {
+		t.Fatalf("Expected 2 centroids, got %d", len(res.Centroids))
+	}
+
+	// First three and last three rows must share clusters
+	for i := 1; i < 3; i++ {
+		if res.Assignments[i] != res.Assignments[0] {
+			t.Errorf("Expected row %d in cluster %d, got %d", i, res.Assignments[0], res.Assignments[i])
+		}
+		if res.Assignments[i+3] != res.Assignments[3] {
+			t.Errorf("Expected row %d in cluster %d, got %d", i+3, res.Assignments[3], res.Assignments[i+3])
+		}
+	}
+	if res.Assignments[0] == res.Assignments[3] {
+		t.Errorf("Expected separate clusters, got %v", res.Assignments)
+	}
+}
+
+func TestKMeansErrors(t *testing.T) {
+	if _, err := KMeans([][]float32{{1, 0}}, 2, KMeansOptions{}); err == nil {
+		t.Error("Expected error for k larger than rows count")
+	}
+	if _, err := KMeans([][]float32{{1, 0}, {1}}, 1, KMeansOptions{}); err == nil {
+		t.Error("Expected error for size mismatch")
+	}
+}
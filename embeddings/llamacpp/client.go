@@ -17,6 +17,24 @@ var (
 	ErrNoData          = errors.New("no embedding data in response")
 )
 
+// ServerError is returned when the server responds with non-OK status.
+// It matches ErrServerResponse with errors.Is.
+type ServerError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("%s: status %d, body: %s", ErrServerResponse, e.StatusCode, e.Body)
+}
+
+func (e *ServerError) Unwrap() error { return ErrServerResponse }
+
+// Temporary reports whether the request may succeed if retried (429 or 5xx status).
+func (e *ServerError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
 // Client implements the Embedder interface for llama-cpp server
 type Client struct {
 	baseURL    string
@@ -93,7 +111,7 @@ func (c *Client) Embed(ctx context.Context, chunk []byte) ([]float32, error) {
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%w: status %d, body: %s", ErrServerResponse, resp.StatusCode, string(body))
+		return nil, &ServerError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	// Parse response
@@ -3,9 +3,12 @@ package llamacpp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/webzak/mindstore/embeddings"
 )
 
 func TestClient_Embed(t *testing.T) {
@@ -169,3 +172,49 @@ func TestClient_EmbedBatch_EmptySlice(t *testing.T) {
 		t.Errorf("Expected nil embeddings for empty input, got %v", embeddings)
 	}
 }
+
+func TestClient_Embed_ServerErrorType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).Embed(context.Background(), []byte("test text"))
+	if !errors.Is(err, ErrServerResponse) {
+		t.Fatalf("Expected ErrServerResponse, got %v", err)
+	}
+	var se *ServerError
+	if !errors.As(err, &se) || !se.Temporary() {
+		t.Errorf("Expected temporary ServerError, got %v", err)
+	}
+}
+
+func TestClient_ReRank(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/rerank" {
+			t.Errorf("Expected /v1/rerank path, got %s", r.URL.Path)
+		}
+		var req rerankRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if req.Query != "query" || len(req.Documents) != 2 {
+			t.Errorf("Unexpected request: %+v", req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"index":0,"relevance_score":0.1},{"index":1,"relevance_score":0.9}]}`))
+	}))
+	defer server.Close()
+
+	candidates := []embeddings.Candidate{
+		{Distance: embeddings.Distance{ID: 10, Value: 0.8}, Data: []byte("first")},
+		{Distance: embeddings.Distance{ID: 20, Value: 0.7}, Data: []byte("second")},
+	}
+	res, err := New(server.URL).ReRank(context.Background(), []byte("query"), candidates)
+	if err != nil {
+		t.Fatalf("ReRank failed: %v", err)
+	}
+	if len(res) != 2 || res[0].ID != 20 || res[0].Value != 0.9 || res[1].ID != 10 {
+		t.Errorf("Unexpected rerank result: %+v", res)
+	}
+}
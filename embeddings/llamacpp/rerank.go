@@ -0,0 +1,95 @@
+package llamacpp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/webzak/mindstore/embeddings"
+)
+
+// rerankRequest represents the request body for llama-cpp rerank API
+type rerankRequest struct {
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+}
+
+// rerankResponse represents the response from llama-cpp rerank API
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float32 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// ReRank scores candidates with the llama-cpp rerank endpoint (server started with a reranking model).
+// The returned candidates are ordered by descending relevance score.
+func (c *Client) ReRank(ctx context.Context, query []byte, candidates []embeddings.Candidate) ([]embeddings.Candidate, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyText
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	reqBody := rerankRequest{
+		Query:     string(query),
+		Documents: make([]string, len(candidates)),
+	}
+	for i, cand := range candidates {
+		reqBody.Documents[i] = string(cand.Data)
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := c.baseURL + "/v1/rerank"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &ServerError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var rerankResp rerankResponse
+	if err := json.Unmarshal(body, &rerankResp); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResponse, err.Error())
+	}
+	if len(rerankResp.Results) != len(candidates) {
+		return nil, fmt.Errorf("%w: expected %d results, got %d", ErrInvalidResponse, len(candidates), len(rerankResp.Results))
+	}
+
+	res := make([]embeddings.Candidate, len(candidates))
+	seen := make([]bool, len(candidates))
+	for i, r := range rerankResp.Results {
+		if r.Index < 0 || r.Index >= len(candidates) || seen[r.Index] {
+			return nil, fmt.Errorf("%w: invalid result index %d", ErrInvalidResponse, r.Index)
+		}
+		seen[r.Index] = true
+		res[i] = candidates[r.Index]
+		res[i].Value = r.RelevanceScore
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Value > res[j].Value
+	})
+	return res, nil
+}
@@ -1,6 +1,7 @@
 package embeddings
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sort"
@@ -25,10 +26,24 @@ type Distance struct {
 // The results can be limited by limit value, 0 means return all
 // The results are ordered by sort order
 func CosineSimRanking(rows [][]float32, vector []float32, sortOrder SortOrder, limit int) ([]Distance, error) {
+	return CosineSimRankingContext(context.Background(), rows, vector, sortOrder, limit)
+}
+
+// ctxCheckInterval is the amount of rows processed between context checks
+const ctxCheckInterval = 1024
+
+// CosineSimRankingContext is CosineSimRanking which stops with ctx error when ctx is done.
+// The context is checked every ctxCheckInterval rows.
+func CosineSimRankingContext(ctx context.Context, rows [][]float32, vector []float32, sortOrder SortOrder, limit int) ([]Distance, error) {
 	lenRows := len(rows)
 	lenVector := len(vector)
 	res := make([]Distance, lenRows)
 	for i, row := range rows {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
 		if len(row) != lenVector {
 			return nil, fmt.Errorf("vector size mismatch: expected: %d, actual: %d", lenVector, len(row))
 		}
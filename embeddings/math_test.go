@@ -1,6 +1,8 @@
 package embeddings
 
 import (
+	"context"
+	"errors"
 	"math"
 	"testing"
 )
@@ -333,3 +335,15 @@ func BenchmarkCosineSimRanking(b *testing.B) {
 		CosineSimRanking(rows, vector, SortDesc, 10)
 	}
 }
+
+// TestCosineSimRankingContext tests that cancelled context stops ranking
+func TestCosineSimRankingContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rows := [][]float32{{1.0, 0.0}}
+	_, err := CosineSimRankingContext(ctx, rows, []float32{1.0, 0.0}, SortDesc, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
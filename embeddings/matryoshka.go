@@ -0,0 +1,46 @@
+package embeddings
+
+import (
+	"fmt"
+	"math"
+)
+
+// Normalize scales vector in place to unit length. Zero vectors are left unchanged.
+func Normalize(v []float32) {
+	var s float32
+	for _, x := range v {
+		s += x * x
+	}
+	if s == 0 {
+		return
+	}
+	inv := float32(1 / math.Sqrt(float64(s)))
+	for i := range v {
+		v[i] *= inv
+	}
+}
+
+// Truncate returns a new vector with the first dim components of v re-normalized
+// to unit length, as used with Matryoshka representation learning models.
+func Truncate(v []float32, dim int) ([]float32, error) {
+	if dim <= 0 || dim > len(v) {
+		return nil, fmt.Errorf("truncation dimension must be between 1 and %d", len(v))
+	}
+	res := make([]float32, dim)
+	copy(res, v[:dim])
+	Normalize(res)
+	return res, nil
+}
+
+// TruncateRows applies Truncate to every row.
+func TruncateRows(rows [][]float32, dim int) ([][]float32, error) {
+	res := make([][]float32, len(rows))
+	for i, row := range rows {
+		t, err := Truncate(row, dim)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+		res[i] = t
+	}
+	return res, nil
+}
@@ -0,0 +1,35 @@
+package embeddings
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	v := []float32{3, 4, 100}
+	res, err := Truncate(v, 2)
+	if err != nil {
+		t.Fatalf("Truncate returned error: %v", err)
+	}
+	if len(res) != 2 || math.Abs(float64(res[0]-0.6)) > 1e-6 || math.Abs(float64(res[1]-0.8)) > 1e-6 {
+		t.Errorf("Expected [0.6 0.8], got %v", res)
+	}
+	if v[0] != 3 {
+		t.Errorf("Truncate modified input vector: %v", v)
+	}
+
+	if _, err := Truncate(v, 4); err == nil {
+		t.Error("Expected error for dimension larger than vector")
+	}
+	if _, err := Truncate(v, 0); err == nil {
+		t.Error("Expected error for zero dimension")
+	}
+}
+
+func TestNormalizeZero(t *testing.T) {
+	v := []float32{0, 0}
+	Normalize(v)
+	if v[0] != 0 || v[1] != 0 {
+		t.Errorf("Expected zero vector unchanged, got %v", v)
+	}
+}
@@ -0,0 +1,411 @@
+// Package npy reads and writes vectors and IDs in numpy .npy and .npz formats,
+// so embeddings produced by Python pipelines can be bulk loaded.
+package npy
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// headerAlign is the alignment of magic, version, header length and header
+	headerAlign = 64
+	// maxHeaderLen limits header size accepted by readers
+	maxHeaderLen = 1 << 16
+	// maxDim limits vector size accepted by ReadVectors
+	maxDim = 1 << 16
+	// maxArrayBytes limits declared array data size accepted by readers
+	maxArrayBytes = 1 << 34
+
+	// VectorsName and IDsName are the array names used in npz archives
+	VectorsName = "vectors.npy"
+	IDsName     = "ids.npy"
+)
+
+var magic = []byte("\x93NUMPY")
+
+// ErrFormat is returned when input is not a supported npy array
+var ErrFormat = errors.New("invalid npy format")
+
+// header describes an npy array
+type header struct {
+	descr string
+	shape []int
+}
+
+// WriteVectors writes rows as a 2D little endian float32 array.
+// All rows must have the same size.
+func WriteVectors(w io.Writer, rows [][]float32) error {
+	dim := 0
+	if len(rows) > 0 {
+		dim = len(rows[0])
+	}
+	for i, row := range rows {
+		if len(row) != dim {
+			return fmt.Errorf("row %d size mismatch, expected: %d, actual: %d", i, dim, len(row))
+		}
+	}
+	if err := writeHeader(w, header{descr: "<f4", shape: []int{len(rows), dim}}); err != nil {
+		return err
+	}
+	buf := make([]byte, dim*4)
+	for _, row := range rows {
+		for j, v := range row {
+			binary.LittleEndian.PutUint32(buf[j*4:], math.Float32bits(v))
+		}
+		if _, err := w.Write(buf); err != nil {
+			return fmt.Errorf("failed to write vectors: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadVectors reads a 2D float32 or float64 array as rows.
+// Float64 values are converted to float32.
+func ReadVectors(r io.Reader) ([][]float32, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.shape) != 2 {
+		return nil, fmt.Errorf("%w: expected 2D array, got shape %v", ErrFormat, h.shape)
+	}
+	var size int
+	switch h.descr {
+	case "<f4":
+		size = 4
+	case "<f8":
+		size = 8
+	default:
+		return nil, fmt.Errorf("%w: unsupported vector dtype %s", ErrFormat, h.descr)
+	}
+
+	n, dim := h.shape[0], h.shape[1]
+	if dim > maxDim {
+		return nil, fmt.Errorf("%w: vector size %d exceeds %d", ErrFormat, dim, maxDim)
+	}
+	if n > 0 && dim == 0 {
+		return nil, fmt.Errorf("%w: %d rows of zero size", ErrFormat, n)
+	}
+	if err := checkArraySize(r, n, dim*size); err != nil {
+		return nil, err
+	}
+	// the declared size is checked against remaining input only when it is
+	// known, rows are still allocated as they are read
+	rows := make([][]float32, 0, min(n, 1024))
+	buf := make([]byte, dim*size)
+	for range n {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("failed to read vectors: %w", err)
+		}
+		row := make([]float32, dim)
+		for j := range row {
+			if size == 4 {
+				row[j] = math.Float32frombits(binary.LittleEndian.Uint32(buf[j*4:]))
+			} else {
+				row[j] = float32(math.Float64frombits(binary.LittleEndian.Uint64(buf[j*8:])))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// WriteIDs writes ids as a 1D little endian uint32 array.
+func WriteIDs(w io.Writer, ids []uint32) error {
+	if err := writeHeader(w, header{descr: "<u4", shape: []int{len(ids)}}); err != nil {
+		return err
+	}
+	buf := make([]byte, len(ids)*4)
+	for i, id := range ids {
+		binary.LittleEndian.PutUint32(buf[i*4:], id)
+	}
+	if _, err := w.Write(buf); err != nil {
+		return fmt.Errorf("failed to write ids: %w", err)
+	}
+	return nil
+}
+
+// ReadIDs reads a 1D integer array of ids.
+// Signed and 64 bit arrays are accepted as long as values fit uint32.
+func ReadIDs(r io.Reader) ([]uint32, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.shape) != 1 {
+		return nil, fmt.Errorf("%w: expected 1D array, got shape %v", ErrFormat, h.shape)
+	}
+	var size int
+	switch h.descr {
+	case "<u4", "<i4":
+		size = 4
+	case "<u8", "<i8":
+		size = 8
+	default:
+		return nil, fmt.Errorf("%w: unsupported id dtype %s", ErrFormat, h.descr)
+	}
+
+	if err := checkArraySize(r, h.shape[0], size); err != nil {
+		return nil, err
+	}
+	ids := make([]uint32, 0, min(h.shape[0], 1<<16))
+	buf := make([]byte, size)
+	for i := range h.shape[0] {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("failed to read ids: %w", err)
+		}
+		var v int64
+		switch h.descr {
+		case "<u4":
+			v = int64(binary.LittleEndian.Uint32(buf))
+		case "<i4":
+			v = int64(int32(binary.LittleEndian.Uint32(buf)))
+		case "<u8":
+			u := binary.LittleEndian.Uint64(buf)
+			if u > math.MaxUint32 {
+				return nil, fmt.Errorf("id %d at position %d is out of uint32 range", u, i)
+			}
+			v = int64(u)
+		case "<i8":
+			v = int64(binary.LittleEndian.Uint64(buf))
+		}
+		if v < 0 || v > math.MaxUint32 {
+			return nil, fmt.Errorf("id %d at position %d is out of uint32 range", v, i)
+		}
+		ids = append(ids, uint32(v))
+	}
+	return ids, nil
+}
+
+// WriteNPZ writes an uncompressed npz archive with vectors and ids arrays.
+func WriteNPZ(w io.Writer, rows [][]float32, ids []uint32) error {
+	if len(ids) != len(rows) {
+		return fmt.Errorf("amount of ids %d does not match amount of rows %d", len(ids), len(rows))
+	}
+	zw := zip.NewWriter(w)
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: VectorsName, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("failed to create npz entry: %w", err)
+	}
+	if err := WriteVectors(fw, rows); err != nil {
+		return err
+	}
+	fw, err = zw.CreateHeader(&zip.FileHeader{Name: IDsName, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("failed to create npz entry: %w", err)
+	}
+	if err := WriteIDs(fw, ids); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadNPZ reads vectors and ids arrays from an npz archive.
+// IDs are optional: nil is returned when the archive has no ids array.
+func ReadNPZ(r io.ReaderAt, size int64) ([][]float32, []uint32, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open npz: %w", err)
+	}
+
+	var rows [][]float32
+	var ids []uint32
+	found := false
+	for _, f := range zr.File {
+		switch f.Name {
+		case VectorsName:
+			err = readEntry(f, func(r io.Reader) (err error) {
+				rows, err = ReadVectors(r)
+				return err
+			})
+			found = true
+		case IDsName:
+			err = readEntry(f, func(r io.Reader) (err error) {
+				ids, err = ReadIDs(r)
+				return err
+			})
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("%w: npz has no %s", ErrFormat, VectorsName)
+	}
+	if ids != nil && len(ids) != len(rows) {
+		return nil, nil, fmt.Errorf("amount of ids %d does not match amount of rows %d", len(ids), len(rows))
+	}
+	return rows, ids, nil
+}
+
+func readEntry(f *zip.File, fn func(io.Reader) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open npz entry %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	// limited reader lets array readers check declared size against entry size
+	return fn(&io.LimitedReader{R: rc, N: int64(f.UncompressedSize64)})
+}
+
+// checkArraySize verifies that n items of itemSize bytes fit maxArrayBytes
+// and the remaining input of r when its length is known
+func checkArraySize(r io.Reader, n, itemSize int) error {
+	if n == 0 || itemSize == 0 {
+		return nil
+	}
+	if n > maxArrayBytes/itemSize {
+		return fmt.Errorf("%w: array data size exceeds %d bytes", ErrFormat, int64(maxArrayBytes))
+	}
+	total := int64(n) * int64(itemSize)
+	if rem, ok := remaining(r); ok && total > rem {
+		return fmt.Errorf("%w: array data size %d exceeds remaining input %d", ErrFormat, total, rem)
+	}
+	return nil
+}
+
+// remaining returns the amount of unread bytes of r if it is known
+func remaining(r io.Reader) (int64, bool) {
+	switch v := r.(type) {
+	case *io.LimitedReader:
+		return v.N, true
+	case interface{ Len() int }:
+		return int64(v.Len()), true
+	}
+	return 0, false
+}
+
+// writeHeader writes npy version 1.0 magic and header padded to headerAlign
+func writeHeader(w io.Writer, h header) error {
+	shape := make([]string, len(h.shape))
+	for i, s := range h.shape {
+		shape[i] = strconv.Itoa(s)
+	}
+	shapeStr := strings.Join(shape, ", ")
+	if len(shape) == 1 {
+		shapeStr += ","
+	}
+	dict := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", h.descr, shapeStr)
+
+	// magic + version + header length + dict + newline
+	total := len(magic) + 2 + 2 + len(dict) + 1
+	pad := (headerAlign - total%headerAlign) % headerAlign
+	dict += strings.Repeat(" ", pad) + "\n"
+
+	var buf bytes.Buffer
+	buf.Write(magic)
+	buf.Write([]byte{1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint16(len(dict)))
+	buf.WriteString(dict)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	return nil
+}
+
+// readHeader reads npy magic and header of version 1.0, 2.0 or 3.0
+func readHeader(r io.Reader) (header, error) {
+	var h header
+	pre := make([]byte, len(magic)+2)
+	if _, err := io.ReadFull(r, pre); err != nil {
+		return h, fmt.Errorf("failed to read header: %w", err)
+	}
+	if !bytes.Equal(pre[:len(magic)], magic) {
+		return h, fmt.Errorf("%w: bad magic", ErrFormat)
+	}
+
+	var hlen int
+	switch pre[len(magic)] {
+	case 1:
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return h, fmt.Errorf("failed to read header: %w", err)
+		}
+		hlen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return h, fmt.Errorf("failed to read header: %w", err)
+		}
+		if n > maxHeaderLen {
+			return h, fmt.Errorf("%w: header length %d exceeds %d", ErrFormat, n, maxHeaderLen)
+		}
+		hlen = int(n)
+	default:
+		return h, fmt.Errorf("%w: unsupported version %d", ErrFormat, pre[len(magic)])
+	}
+
+	buf := make([]byte, hlen)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return h, fmt.Errorf("failed to read header: %w", err)
+	}
+	return parseHeader(string(buf))
+}
+
+// parseHeader parses the python dict literal of npy header
+func parseHeader(s string) (header, error) {
+	var h header
+	descr, ok := dictValue(s, "descr")
+	if !ok {
+		return h, fmt.Errorf("%w: header has no descr", ErrFormat)
+	}
+	h.descr = strings.Trim(descr, "'\"")
+
+	order, ok := dictValue(s, "fortran_order")
+	if !ok {
+		return h, fmt.Errorf("%w: header has no fortran_order", ErrFormat)
+	}
+	if order != "False" {
+		return h, fmt.Errorf("%w: fortran order is not supported", ErrFormat)
+	}
+
+	shape, ok := dictValue(s, "shape")
+	if !ok {
+		return h, fmt.Errorf("%w: header has no shape", ErrFormat)
+	}
+	for _, p := range strings.Split(strings.Trim(shape, "()"), ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return h, fmt.Errorf("%w: bad shape %s", ErrFormat, shape)
+		}
+		h.shape = append(h.shape, n)
+	}
+	return h, nil
+}
+
+// dictValue returns raw value of key in python dict literal s
+func dictValue(s, key string) (string, bool) {
+	i := strings.Index(s, "'"+key+"'")
+	if i < 0 {
+		return "", false
+	}
+	rest := strings.TrimLeft(s[i+len(key)+2:], " ")
+	if !strings.HasPrefix(rest, ":") {
+		return "", false
+	}
+	rest = strings.TrimLeft(rest[1:], " ")
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			return "", false
+		}
+		return rest[:end+1], true
+	}
+	end := strings.IndexAny(rest, ",}")
+	if end < 0 {
+		return "", false
+	}
+	return strings.TrimSpace(rest[:end]), true
+}
@@ -0,0 +1,159 @@
+package npy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestVectorsRoundTrip(t *testing.T) {
+	rows := [][]float32{{1, 2, 3}, {-1, 0.5, 0}}
+
+	var buf bytes.Buffer
+	if err := WriteVectors(&buf, rows); err != nil {
+		t.Fatalf("WriteVectors failed: %v", err)
+	}
+	b := buf.Bytes()
+	hlen := int(binary.LittleEndian.Uint16(b[8:]))
+	if (10+hlen)%headerAlign != 0 {
+		t.Errorf("header is not aligned: %d", 10+hlen)
+	}
+	if len(b) != 10+hlen+len(rows)*3*4 {
+		t.Errorf("unexpected size: %d", len(b))
+	}
+
+	got, err := ReadVectors(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadVectors failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("expected %v, got %v", rows, got)
+	}
+}
+
+func TestReadVectorsFloat64(t *testing.T) {
+	var buf bytes.Buffer
+	writeHeader(&buf, header{descr: "<f8", shape: []int{1, 2}})
+	binary.Write(&buf, binary.LittleEndian, []float64{1.5, -2})
+
+	got, err := ReadVectors(&buf)
+	if err != nil {
+		t.Fatalf("ReadVectors failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, [][]float32{{1.5, -2}}) {
+		t.Errorf("unexpected rows: %v", got)
+	}
+}
+
+func TestIDsRoundTrip(t *testing.T) {
+	ids := []uint32{1, 5, math.MaxUint32}
+
+	var buf bytes.Buffer
+	if err := WriteIDs(&buf, ids); err != nil {
+		t.Fatalf("WriteIDs failed: %v", err)
+	}
+	got, err := ReadIDs(&buf)
+	if err != nil {
+		t.Fatalf("ReadIDs failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, ids) {
+		t.Errorf("expected %v, got %v", ids, got)
+	}
+}
+
+func TestReadIDsInt64(t *testing.T) {
+	var buf bytes.Buffer
+	writeHeader(&buf, header{descr: "<i8", shape: []int{2}})
+	binary.Write(&buf, binary.LittleEndian, []int64{7, 9})
+	got, err := ReadIDs(&buf)
+	if err != nil {
+		t.Fatalf("ReadIDs failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []uint32{7, 9}) {
+		t.Errorf("unexpected ids: %v", got)
+	}
+
+	buf.Reset()
+	writeHeader(&buf, header{descr: "<i8", shape: []int{1}})
+	binary.Write(&buf, binary.LittleEndian, []int64{-1})
+	if _, err := ReadIDs(&buf); err == nil {
+		t.Error("expected error for negative id")
+	}
+}
+
+func TestNPZRoundTrip(t *testing.T) {
+	rows := [][]float32{{1, 2}, {3, 4}, {5, 6}}
+	ids := []uint32{10, 20, 30}
+
+	var buf bytes.Buffer
+	if err := WriteNPZ(&buf, rows, ids); err != nil {
+		t.Fatalf("WriteNPZ failed: %v", err)
+	}
+	gotRows, gotIDs, err := ReadNPZ(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadNPZ failed: %v", err)
+	}
+	if !reflect.DeepEqual(gotRows, rows) || !reflect.DeepEqual(gotIDs, ids) {
+		t.Errorf("unexpected result: %v %v", gotRows, gotIDs)
+	}
+
+	if err := WriteNPZ(&buf, rows, ids[:1]); err == nil {
+		t.Error("expected error for ids size mismatch")
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	hdr := func(descr string, shape ...int) []byte {
+		var buf bytes.Buffer
+		writeHeader(&buf, header{descr: descr, shape: shape})
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"bad magic", []byte("NOTNUMPY\x00\x00")},
+		{"1D vectors", hdr("<f4", 3)},
+		{"int vectors", hdr("<i4", 1, 2)},
+		{"huge dim", hdr("<f4", 1, 1<<30)},
+		{"truncated data", hdr("<f4", 1<<30, 4)},
+		{"zero dim", hdr("<f4", math.MaxInt, 0)},
+		{"size overflow", hdr("<f8", math.MaxInt/4, 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadVectors(bytes.NewReader(tt.data)); err == nil {
+				t.Error("expected error")
+			}
+			// input of unknown length must fail without reading rows
+			if _, err := ReadVectors(struct{ io.Reader }{bytes.NewReader(tt.data)}); err == nil {
+				t.Error("expected error for unknown length input")
+			}
+		})
+	}
+
+	// declared size is checked against remaining input before reading
+	if _, err := ReadVectors(bytes.NewReader(hdr("<f4", 1<<30, 4))); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+	if _, err := ReadIDs(bytes.NewReader(hdr("<u8", 1<<40))); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+
+	if _, err := ReadVectors(bytes.NewReader(hdr("<i4", 1, 2))); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+}
+
+func TestParseHeaderFortran(t *testing.T) {
+	_, err := parseHeader("{'descr': '<f4', 'fortran_order': True, 'shape': (2, 3), }")
+	if !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+}
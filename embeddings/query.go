@@ -0,0 +1,66 @@
+package embeddings
+
+import "fmt"
+
+// RocchioWeights defines weights of the Rocchio query combination
+type RocchioWeights struct {
+	Query    float32 // weight of the original query (alpha)
+	Positive float32 // weight of positive examples mean (beta)
+	Negative float32 // weight of negative examples mean (gamma)
+}
+
+// DefaultRocchioWeights are commonly used Rocchio weights
+var DefaultRocchioWeights = RocchioWeights{Query: 1.0, Positive: 0.75, Negative: 0.15}
+
+// Rocchio combines query with positive and negative example vectors:
+// alpha*query + beta*mean(positives) - gamma*mean(negatives).
+// Query may be nil to search by examples only. At least one vector must be provided.
+func Rocchio(query []float32, positives, negatives [][]float32, w RocchioWeights) ([]float32, error) {
+	dim := len(query)
+	if dim == 0 {
+		switch {
+		case len(positives) > 0:
+			dim = len(positives[0])
+		case len(negatives) > 0:
+			dim = len(negatives[0])
+		default:
+			return nil, fmt.Errorf("no query or example vectors provided")
+		}
+	}
+
+	res := make([]float32, dim)
+	if query != nil {
+		for i, v := range query {
+			res[i] = w.Query * v
+		}
+	}
+	if err := addMean(res, positives, w.Positive); err != nil {
+		return nil, err
+	}
+	if err := addMean(res, negatives, -w.Negative); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CombineExamples returns mean(positives) - mean(negatives).
+func CombineExamples(positives, negatives [][]float32) ([]float32, error) {
+	return Rocchio(nil, positives, negatives, RocchioWeights{Positive: 1, Negative: 1})
+}
+
+// addMean adds weight*mean(rows) to dst
+func addMean(dst []float32, rows [][]float32, weight float32) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	w := weight / float32(len(rows))
+	for _, row := range rows {
+		if len(row) != len(dst) {
+			return fmt.Errorf("vector size mismatch: expected: %d, actual: %d", len(dst), len(row))
+		}
+		for i, v := range row {
+			dst[i] += w * v
+		}
+	}
+	return nil
+}
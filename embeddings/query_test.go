@@ -0,0 +1,39 @@
+package embeddings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCombineExamples(t *testing.T) {
+	pos := [][]float32{{1, 0, 0}, {1, 1, 0}}
+	neg := [][]float32{{0, 1, 0}}
+
+	res, err := CombineExamples(pos, neg)
+	if err != nil {
+		t.Fatalf("CombineExamples returned error: %v", err)
+	}
+	expected := []float32{1, -0.5, 0}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %v, got %v", expected, res)
+	}
+}
+
+func TestRocchio(t *testing.T) {
+	res, err := Rocchio([]float32{1, 1}, [][]float32{{2, 0}}, [][]float32{{0, 2}},
+		RocchioWeights{Query: 1, Positive: 0.5, Negative: 0.5})
+	if err != nil {
+		t.Fatalf("Rocchio returned error: %v", err)
+	}
+	expected := []float32{2, 0}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %v, got %v", expected, res)
+	}
+
+	if _, err := Rocchio(nil, nil, nil, DefaultRocchioWeights); err == nil {
+		t.Error("Expected error for empty input")
+	}
+	if _, err := Rocchio([]float32{1, 1}, [][]float32{{1}}, nil, DefaultRocchioWeights); err == nil {
+		t.Error("Expected error for size mismatch")
+	}
+}
@@ -0,0 +1,16 @@
+package embeddings
+
+import "context"
+
+// Candidate is a search result with its data passed to a ReRanker
+type Candidate struct {
+	Distance
+	Data []byte
+}
+
+// ReRanker defines the interface for re-scoring search results
+// with a model that sees both query and item data (e.g. a cross-encoder)
+type ReRanker interface {
+	// ReRank returns candidates ordered by descending relevance with Value set to the new score
+	ReRank(ctx context.Context, query []byte, candidates []Candidate) ([]Candidate, error)
+}
@@ -0,0 +1,160 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the provider while the circuit breaker is open
+var ErrCircuitOpen = errors.New("embedder circuit breaker is open")
+
+// RetryOptions configures ResilientEmbedder
+type RetryOptions struct {
+	// Timeout limits a single provider call, 0 means no limit
+	Timeout time.Duration
+	// MaxRetries is the amount of retries after the first failed attempt
+	MaxRetries int
+	// BaseDelay is the delay before the first retry, doubled for each next one
+	BaseDelay time.Duration
+	// MaxDelay caps the retry delay, 0 means no cap
+	MaxDelay time.Duration
+	// Retryable reports whether error is worth retrying, nil means IsTemporary
+	Retryable func(error) bool
+	// BreakerThreshold is the amount of consecutive failed calls opening the breaker, 0 disables it
+	BreakerThreshold int
+	// BreakerCooldown is how long the breaker stays open before a trial call is allowed
+	BreakerCooldown time.Duration
+}
+
+// ResilientEmbedder wraps an Embedder with timeouts, exponential backoff retries
+// and a circuit breaker. It is safe for concurrent use.
+type ResilientEmbedder struct {
+	e    Embedder
+	opts RetryOptions
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool // a half-open trial call is in progress
+}
+
+// NewResilient creates a ResilientEmbedder around e.
+func NewResilient(e Embedder, opts RetryOptions) *ResilientEmbedder {
+	if opts.Retryable == nil {
+		opts.Retryable = IsTemporary
+	}
+	return &ResilientEmbedder{e: e, opts: opts}
+}
+
+// IsTemporary reports whether err or any error it wraps has Temporary() returning true,
+// or is a per-attempt timeout.
+func IsTemporary(err error) bool {
+	var t interface{ Temporary() bool }
+	if errors.As(err, &t) {
+		return t.Temporary()
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// Embed calls the wrapped Embed applying retry and breaker policies.
+func (r *ResilientEmbedder) Embed(ctx context.Context, data []byte) ([]float32, error) {
+	var res []float32
+	err := r.call(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = r.e.Embed(ctx, data)
+		return err
+	})
+	return res, err
+}
+
+// EmbedBatch calls the wrapped EmbedBatch applying retry and breaker policies.
+func (r *ResilientEmbedder) EmbedBatch(ctx context.Context, chunks [][]byte) ([][]float32, error) {
+	var res [][]float32
+	err := r.call(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = r.e.EmbedBatch(ctx, chunks)
+		return err
+	})
+	return res, err
+}
+
+func (r *ResilientEmbedder) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := r.acquire(); err != nil {
+		return err
+	}
+
+	var err error
+	delay := r.opts.BaseDelay
+	for attempt := 0; ; attempt++ {
+		err = r.attempt(ctx, fn)
+		if err == nil || ctx.Err() != nil || attempt >= r.opts.MaxRetries || !r.opts.Retryable(err) {
+			break
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			r.release(ctx, ctx.Err())
+			return ctx.Err()
+		}
+		delay *= 2
+		if r.opts.MaxDelay > 0 && delay > r.opts.MaxDelay {
+			delay = r.opts.MaxDelay
+		}
+	}
+
+	r.release(ctx, err)
+	return err
+}
+
+// attempt runs fn with per-attempt timeout
+func (r *ResilientEmbedder) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.opts.Timeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.opts.Timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+// acquire checks the breaker state before a call
+func (r *ResilientEmbedder) acquire() error {
+	if r.opts.BreakerThreshold <= 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures < r.opts.BreakerThreshold {
+		return nil
+	}
+	if time.Now().Before(r.openUntil) || r.trial {
+		return ErrCircuitOpen
+	}
+	// Half-open: let a single trial call through
+	r.trial = true
+	return nil
+}
+
+// release records the call result in the breaker state.
+// Only retryable errors of calls not cancelled by the caller count as failures,
+// other errors leave the state unchanged apart from ending a trial call.
+func (r *ResilientEmbedder) release(ctx context.Context, err error) {
+	if r.opts.BreakerThreshold <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trial = false
+	if err == nil {
+		r.failures = 0
+		return
+	}
+	if ctx.Err() != nil || !r.opts.Retryable(err) {
+		return
+	}
+	r.failures++
+	if r.failures >= r.opts.BreakerThreshold {
+		r.openUntil = time.Now().Add(r.opts.BreakerCooldown)
+	}
+}
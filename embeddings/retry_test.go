@@ -0,0 +1,146 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type tempError struct{}
+
+func (tempError) Error() string   { return "temporary" }
+func (tempError) Temporary() bool { return true }
+
+// flakyEmbedder fails the first failures calls
+type flakyEmbedder struct {
+	failures int
+	calls    int
+	err      error
+}
+
+func (e *flakyEmbedder) Embed(ctx context.Context, data []byte) ([]float32, error) {
+	e.calls++
+	if e.calls <= e.failures {
+		return nil, e.err
+	}
+	return []float32{1}, nil
+}
+
+func (e *flakyEmbedder) EmbedBatch(ctx context.Context, chunks [][]byte) ([][]float32, error) {
+	vec, err := e.Embed(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return [][]float32{vec}, nil
+}
+
+func TestResilientRetries(t *testing.T) {
+	inner := &flakyEmbedder{failures: 2, err: tempError{}}
+	r := NewResilient(inner, RetryOptions{MaxRetries: 3, BaseDelay: time.Millisecond})
+
+	vec, err := r.Embed(context.Background(), []byte("x"))
+	if err != nil {
+		t.Fatalf("Embed returned error: %v", err)
+	}
+	if len(vec) != 1 || inner.calls != 3 {
+		t.Errorf("Expected success on third call, got %d calls", inner.calls)
+	}
+
+	// Permanent errors are not retried
+	inner = &flakyEmbedder{failures: 5, err: errors.New("bad request")}
+	r = NewResilient(inner, RetryOptions{MaxRetries: 3, BaseDelay: time.Millisecond})
+	if _, err := r.Embed(context.Background(), []byte("x")); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if inner.calls != 1 {
+		t.Errorf("Expected 1 call, got %d", inner.calls)
+	}
+}
+
+func TestResilientTimeout(t *testing.T) {
+	slow := embedFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	r := NewResilient(slow, RetryOptions{Timeout: time.Millisecond})
+	_, err := r.Embed(context.Background(), []byte("x"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestResilientCircuitBreaker(t *testing.T) {
+	inner := &flakyEmbedder{failures: 2, err: tempError{}}
+	r := NewResilient(inner, RetryOptions{BreakerThreshold: 2, BreakerCooldown: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	r.Embed(ctx, nil)
+	r.Embed(ctx, nil)
+	if _, err := r.Embed(ctx, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("Expected 2 calls while open, got %d", inner.calls)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := r.Embed(ctx, nil); err != nil {
+		t.Fatalf("Expected trial call to succeed, got %v", err)
+	}
+	if _, err := r.Embed(ctx, nil); err != nil {
+		t.Fatalf("Expected closed breaker, got %v", err)
+	}
+}
+
+// embedFunc adapts a function to Embedder
+type embedFunc func(ctx context.Context) error
+
+func (f embedFunc) Embed(ctx context.Context, data []byte) ([]float32, error) {
+	return nil, f(ctx)
+}
+
+func (f embedFunc) EmbedBatch(ctx context.Context, chunks [][]byte) ([][]float32, error) {
+	return nil, f(ctx)
+}
+
+func TestResilientBreakerIgnoresNonRetryable(t *testing.T) {
+	inner := &flakyEmbedder{failures: 5, err: errors.New("bad request")}
+	r := NewResilient(inner, RetryOptions{BreakerThreshold: 2, BreakerCooldown: time.Minute})
+	for range 3 {
+		if _, err := r.Embed(context.Background(), nil); errors.Is(err, ErrCircuitOpen) {
+			t.Fatal("Expected non-retryable errors not to open the breaker")
+		}
+	}
+	if r.failures != 0 {
+		t.Errorf("Expected 0 failures, got %d", r.failures)
+	}
+}
+
+func TestResilientBreakerIgnoresCancel(t *testing.T) {
+	slow := embedFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	r := NewResilient(slow, RetryOptions{BreakerThreshold: 1, BreakerCooldown: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.Embed(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected canceled, got %v", err)
+	}
+	if r.failures != 0 || r.trial {
+		t.Errorf("Expected breaker state unchanged, got %d failures", r.failures)
+	}
+
+	// cancellation while waiting between retries
+	inner := &flakyEmbedder{failures: 5, err: tempError{}}
+	r = NewResilient(inner, RetryOptions{MaxRetries: 3, BaseDelay: time.Minute, BreakerThreshold: 1, BreakerCooldown: time.Minute})
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := r.Embed(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+	if r.failures != 0 {
+		t.Errorf("Expected 0 failures, got %d", r.failures)
+	}
+}
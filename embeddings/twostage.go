@@ -0,0 +1,72 @@
+package embeddings
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Prefilter returns up to n candidate rows for query using a cheap method.
+// Returned Distance IDs must be row indexes of the full precision rows.
+type Prefilter func(query []float32, n int) ([]Distance, error)
+
+// TruncatedPrefilter creates a Prefilter ranking rows truncated to dim
+// components (see Truncate). The query is truncated the same way.
+func TruncatedPrefilter(rows [][]float32, dim int) (Prefilter, error) {
+	truncated, err := TruncateRows(rows, dim)
+	if err != nil {
+		return nil, err
+	}
+	return func(query []float32, n int) ([]Distance, error) {
+		q, err := Truncate(query, dim)
+		if err != nil {
+			return nil, err
+		}
+		return CosineSimRanking(truncated, q, SortDesc, n)
+	}, nil
+}
+
+// IVFPrefilter creates a Prefilter scanning nprobe lists of the IVF index.
+func IVFPrefilter(ivf *IVF, nprobe int) Prefilter {
+	return func(query []float32, n int) ([]Distance, error) {
+		return ivf.Search(query, nprobe, n)
+	}
+}
+
+// Rerank re-scores candidates with full precision rows by cosine similarity.
+// The results are ordered by descending similarity and limited by limit, 0 means all.
+func Rerank(rows [][]float32, query []float32, candidates []Distance, limit int) ([]Distance, error) {
+	res := make([]Distance, len(candidates))
+	for i, c := range candidates {
+		if c.ID < 0 || c.ID >= len(rows) {
+			return nil, fmt.Errorf("candidate id %d is out of range", c.ID)
+		}
+		row := rows[c.ID]
+		if len(row) != len(query) {
+			return nil, fmt.Errorf("vector size mismatch: expected: %d, actual: %d", len(query), len(row))
+		}
+		res[i] = Distance{
+			ID:       c.ID,
+			Value:    CosineSim(row, query),
+			Position: c.Position,
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Value > res[j].Value
+	})
+	if limit > 0 && len(res) > limit {
+		return res[:limit], nil
+	}
+	return res, nil
+}
+
+// TwoStageSearch retrieves candidates rows with prefilter and re-ranks them with full precision rows.
+func TwoStageSearch(rows [][]float32, query []float32, prefilter Prefilter, candidates, limit int) ([]Distance, error) {
+	if candidates < limit {
+		return nil, fmt.Errorf("candidates %d must not be less than limit %d", candidates, limit)
+	}
+	found, err := prefilter(query, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("prefilter failed: %w", err)
+	}
+	return Rerank(rows, query, found, limit)
+}
@@ -0,0 +1,38 @@
+package embeddings
+
+import "testing"
+
+func TestTwoStageSearch(t *testing.T) {
+	rows := [][]float32{
+		{1.0, 0.0, 0.0, 1.0},
+		{1.0, 0.0, 1.0, 0.0},
+		{0.0, 1.0, 0.0, 0.0},
+		{1.0, 0.1, 0.0, 0.0},
+	}
+	query := []float32{1.0, 0.0, 1.0, 0.0}
+
+	prefilter, err := TruncatedPrefilter(rows, 2)
+	if err != nil {
+		t.Fatalf("TruncatedPrefilter returned error: %v", err)
+	}
+
+	res, err := TwoStageSearch(rows, query, prefilter, 3, 1)
+	if err != nil {
+		t.Fatalf("TwoStageSearch returned error: %v", err)
+	}
+	// Rows 0, 1 and 3 tie or nearly tie on the first two components, full re-rank picks row 1
+	if len(res) != 1 || res[0].ID != 1 {
+		t.Errorf("Expected row 1, got %v", res)
+	}
+
+	if _, err := TwoStageSearch(rows, query, prefilter, 1, 2); err == nil {
+		t.Error("Expected error for candidates less than limit")
+	}
+}
+
+func TestRerankOutOfRange(t *testing.T) {
+	_, err := Rerank([][]float32{{1}}, []float32{1}, []Distance{{ID: 5}}, 0)
+	if err == nil {
+		t.Error("Expected error for out of range candidate")
+	}
+}
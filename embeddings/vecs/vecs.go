@@ -0,0 +1,103 @@
+// Package vecs reads and writes vectors in .fvecs and .ivecs formats used by
+// Faiss tooling and the texmex ANN benchmark datasets (SIFT, GIST).
+//
+// Each row is stored as a little endian int32 dimension followed by
+// dimension values, float32 for fvecs and int32 for ivecs.
+package vecs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// maxDim limits row dimension accepted by readers
+const maxDim = 1 << 16
+
+// ErrFormat is returned when input is not a valid vecs file
+var ErrFormat = errors.New("invalid vecs format")
+
+// WriteFvecs writes rows in fvecs format.
+func WriteFvecs(w io.Writer, rows [][]float32) error {
+	for i, row := range rows {
+		buf := make([]byte, 4+len(row)*4)
+		binary.LittleEndian.PutUint32(buf, uint32(len(row)))
+		for j, v := range row {
+			binary.LittleEndian.PutUint32(buf[4+j*4:], math.Float32bits(v))
+		}
+		if _, err := w.Write(buf); err != nil {
+			return fmt.Errorf("failed to write row %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ReadFvecs reads all rows from fvecs input.
+func ReadFvecs(r io.Reader) ([][]float32, error) {
+	var rows [][]float32
+	err := readRows(r, func(buf []byte) {
+		row := make([]float32, len(buf)/4)
+		for j := range row {
+			row[j] = math.Float32frombits(binary.LittleEndian.Uint32(buf[j*4:]))
+		}
+		rows = append(rows, row)
+	})
+	return rows, err
+}
+
+// WriteIvecs writes rows in ivecs format. It is used for id maps
+// (one id per row) and ground truth neighbour lists.
+func WriteIvecs(w io.Writer, rows [][]int32) error {
+	for i, row := range rows {
+		buf := make([]byte, 4+len(row)*4)
+		binary.LittleEndian.PutUint32(buf, uint32(len(row)))
+		for j, v := range row {
+			binary.LittleEndian.PutUint32(buf[4+j*4:], uint32(v))
+		}
+		if _, err := w.Write(buf); err != nil {
+			return fmt.Errorf("failed to write row %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ReadIvecs reads all rows from ivecs input.
+func ReadIvecs(r io.Reader) ([][]int32, error) {
+	var rows [][]int32
+	err := readRows(r, func(buf []byte) {
+		row := make([]int32, len(buf)/4)
+		for j := range row {
+			row[j] = int32(binary.LittleEndian.Uint32(buf[j*4:]))
+		}
+		rows = append(rows, row)
+	})
+	return rows, err
+}
+
+// readRows reads rows until EOF passing raw values of each row to fn
+func readRows(r io.Reader, fn func(buf []byte)) error {
+	var dimBuf [4]byte
+	var buf []byte
+	for i := 0; ; i++ {
+		if _, err := io.ReadFull(r, dimBuf[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read row %d: %w", i, err)
+		}
+		dim := int32(binary.LittleEndian.Uint32(dimBuf[:]))
+		if dim < 0 || dim > maxDim {
+			return fmt.Errorf("%w: row %d has dimension %d", ErrFormat, i, dim)
+		}
+		if cap(buf) < int(dim)*4 {
+			buf = make([]byte, int(dim)*4)
+		}
+		buf = buf[:int(dim)*4]
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return fmt.Errorf("failed to read row %d: %w", i, err)
+		}
+		fn(buf)
+	}
+}
@@ -0,0 +1,64 @@
+package vecs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFvecsRoundTrip(t *testing.T) {
+	rows := [][]float32{{1, 2, 3}, {-1, 0.25, 0}}
+
+	var buf bytes.Buffer
+	if err := WriteFvecs(&buf, rows); err != nil {
+		t.Fatalf("WriteFvecs failed: %v", err)
+	}
+	if buf.Len() != 2*(4+3*4) {
+		t.Errorf("unexpected size: %d", buf.Len())
+	}
+	got, err := ReadFvecs(&buf)
+	if err != nil {
+		t.Fatalf("ReadFvecs failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("expected %v, got %v", rows, got)
+	}
+}
+
+func TestIvecsRoundTrip(t *testing.T) {
+	rows := [][]int32{{7}, {1, 2, 3}, {}}
+
+	var buf bytes.Buffer
+	if err := WriteIvecs(&buf, rows); err != nil {
+		t.Fatalf("WriteIvecs failed: %v", err)
+	}
+	got, err := ReadIvecs(&buf)
+	if err != nil {
+		t.Fatalf("ReadIvecs failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("expected %v, got %v", rows, got)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(-1))
+	if _, err := ReadFvecs(&buf); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat for negative dimension, got %v", err)
+	}
+
+	buf.Reset()
+	binary.Write(&buf, binary.LittleEndian, int32(1<<30))
+	if _, err := ReadFvecs(&buf); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat for huge dimension, got %v", err)
+	}
+
+	buf.Reset()
+	binary.Write(&buf, binary.LittleEndian, []int32{4, 1})
+	if _, err := ReadFvecs(&buf); err == nil {
+		t.Error("expected error for truncated row")
+	}
+}
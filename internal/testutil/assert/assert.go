@@ -0,0 +1,39 @@
+// Package assert provides minimal test assertion helpers.
+package assert
+
+import (
+	"reflect"
+	"testing"
+)
+
+// NilError fails the test immediately if err is not nil.
+func NilError(t testing.TB, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// NotNilError fails the test immediately if err is nil.
+func NotNilError(t testing.TB, err error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+// Equal fails the test immediately if expected and actual are not equal.
+func Equal[T comparable](t testing.TB, expected, actual T) {
+	t.Helper()
+	if expected != actual {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
+
+// DeepEqual fails the test immediately if expected and actual are not deeply equal.
+func DeepEqual(t testing.TB, expected, actual any) {
+	t.Helper()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
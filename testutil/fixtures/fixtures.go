@@ -0,0 +1,165 @@
+// Package fixtures generates reproducible datasets for tests and benchmarks.
+// The same options and seed always produce the same records.
+package fixtures
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/webzak/mindstore/db/dataset"
+)
+
+// Unit descriptors used by generated datasets
+const (
+	DescText    = 1 // data is UTF-8 text
+	DescJSON    = 2 // meta is JSON encoded Meta
+	DescFloat32 = 3 // vector is little endian float32 array
+)
+
+// defaultZipfS is the zipf exponent used when Options.ZipfS is not set
+const defaultZipfS = 1.1
+
+// Options configures generated records.
+type Options struct {
+	// Records is the amount of records
+	Records int
+	// Dim is the vector size, zero means records have no vectors
+	Dim int
+	// Seed makes generation reproducible
+	Seed int64
+	// Tags are assigned with zipfian distribution, the first tag is the most frequent.
+	// Defaults to tag-0 ... tag-9.
+	Tags []string
+	// ZipfS is the zipf exponent, must be greater than 1. Defaults to 1.1.
+	ZipfS float64
+}
+
+// Meta is the structured meta of generated records.
+type Meta struct {
+	Seq   int     `json:"seq"`
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+	Date  int64   `json:"date"`
+}
+
+// Item is a generated record.
+type Item struct {
+	Data   []byte
+	Meta   Meta
+	Vector []float32
+}
+
+// baseDate is the date of the first generated record, 2024-01-01 UTC
+const baseDate = 1704067200
+
+// Items generates records for opts.
+func Items(opts Options) ([]Item, error) {
+	if opts.Records < 0 || opts.Dim < 0 {
+		return nil, fmt.Errorf("records and dim cannot be negative")
+	}
+	tags := opts.Tags
+	if len(tags) == 0 {
+		tags = make([]string, 10)
+		for i := range tags {
+			tags[i] = fmt.Sprintf("tag-%d", i)
+		}
+	}
+	s := opts.ZipfS
+	if s == 0 {
+		s = defaultZipfS
+	}
+	if s <= 1 {
+		return nil, fmt.Errorf("zipf exponent must be greater than 1")
+	}
+
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	zipf := rand.NewZipf(rnd, s, 1, uint64(len(tags)-1))
+
+	items := make([]Item, opts.Records)
+	for i := range items {
+		tag := tags[zipf.Uint64()]
+		items[i] = Item{
+			Data: fmt.Appendf(nil, "record %d about %s", i, tag),
+			Meta: Meta{
+				Seq:   i,
+				Tag:   tag,
+				Score: rnd.Float64(),
+				Date:  baseDate + int64(i)*60,
+			},
+		}
+		if opts.Dim > 0 {
+			items[i].Vector = vector(rnd, opts.Dim)
+		}
+	}
+	return items, nil
+}
+
+// Vectors generates n unit length vectors of size dim.
+func Vectors(n, dim int, seed int64) [][]float32 {
+	rnd := rand.New(rand.NewSource(seed))
+	rows := make([][]float32, n)
+	for i := range rows {
+		rows[i] = vector(rnd, dim)
+	}
+	return rows
+}
+
+// Dataset creates a dataset at path filled with generated records.
+// Record IDs follow item order starting from 1.
+func Dataset(path string, opts Options) (*dataset.Dataset, error) {
+	items, err := Items(opts)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := dataset.NewDataset(path, 0, nil, max(len(items), 1))
+	if err != nil {
+		return nil, err
+	}
+	for _, it := range items {
+		meta, err := json.Marshal(it.Meta)
+		if err != nil {
+			ds.Close()
+			return nil, err
+		}
+		var vec dataset.Unit
+		if it.Vector != nil {
+			vec = dataset.NewByteUnit(EncodeVector(it.Vector), DescFloat32)
+		}
+		if _, err := ds.Append(dataset.NewByteUnit(it.Data, DescText), dataset.NewByteUnit(meta, DescJSON), vec); err != nil {
+			ds.Close()
+			return nil, fmt.Errorf("failed to append record %d: %w", it.Meta.Seq, err)
+		}
+	}
+	return ds, nil
+}
+
+// EncodeVector encodes v as little endian float32 array.
+func EncodeVector(v []float32) []byte {
+	buf := make([]byte, len(v)*4)
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(f))
+	}
+	return buf
+}
+
+// vector generates a unit length vector with normally distributed components
+func vector(rnd *rand.Rand, dim int) []float32 {
+	v := make([]float32, dim)
+	var norm float64
+	for i := range v {
+		x := rnd.NormFloat64()
+		v[i] = float32(x)
+		norm += x * x
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		return v
+	}
+	for i := range v {
+		v[i] = float32(float64(v[i]) / norm)
+	}
+	return v
+}
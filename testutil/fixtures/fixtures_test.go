@@ -0,0 +1,84 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestItemsReproducible(t *testing.T) {
+	opts := Options{Records: 100, Dim: 8, Seed: 42}
+	a, err := Items(opts)
+	if err != nil {
+		t.Fatalf("Items failed: %v", err)
+	}
+	b, _ := Items(opts)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("same seed produced different items")
+	}
+
+	opts.Seed = 43
+	c, _ := Items(opts)
+	if reflect.DeepEqual(a, c) {
+		t.Error("different seeds produced same items")
+	}
+}
+
+func TestItemsZipfTags(t *testing.T) {
+	items, err := Items(Options{Records: 2000, Seed: 1, Tags: []string{"a", "b", "c", "d"}})
+	if err != nil {
+		t.Fatalf("Items failed: %v", err)
+	}
+	counts := make(map[string]int)
+	for _, it := range items {
+		counts[it.Meta.Tag]++
+		if it.Vector != nil {
+			t.Fatal("expected no vectors when dim is zero")
+		}
+	}
+	if counts["a"] <= counts["b"] || counts["b"] <= counts["d"] {
+		t.Errorf("expected decreasing tag frequency, got %v", counts)
+	}
+}
+
+func TestItemsErrors(t *testing.T) {
+	if _, err := Items(Options{Records: -1}); err == nil {
+		t.Error("expected error for negative records")
+	}
+	if _, err := Items(Options{Records: 1, ZipfS: 0.5}); err == nil {
+		t.Error("expected error for zipf exponent not greater than 1")
+	}
+}
+
+func TestDataset(t *testing.T) {
+	ds, err := Dataset(filepath.Join(t.TempDir(), "fixture.ds"), Options{Records: 20, Dim: 4, Seed: 7})
+	if err != nil {
+		t.Fatalf("Dataset failed: %v", err)
+	}
+	defer ds.Close()
+
+	n, err := ds.List().Count()
+	if err != nil || n != 20 {
+		t.Fatalf("expected 20 records, got %d, err: %v", n, err)
+	}
+
+	items, _ := Items(Options{Records: 20, Dim: 4, Seed: 7})
+	c, err := ds.Read(5)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	var m Meta
+	if err := json.Unmarshal(c.Meta.Blob(), &m); err != nil {
+		t.Fatalf("failed to decode meta: %v", err)
+	}
+	if m != items[4].Meta {
+		t.Errorf("expected meta %v, got %v", items[4].Meta, m)
+	}
+	if !reflect.DeepEqual(c.Vector.Blob(), EncodeVector(items[4].Vector)) {
+		t.Error("vector mismatch")
+	}
+	if c.Data.Descriptor() != DescText || c.Meta.Descriptor() != DescJSON || c.Vector.Descriptor() != DescFloat32 {
+		t.Error("unexpected descriptors")
+	}
+}